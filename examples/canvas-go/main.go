@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
-	"net"
 	"net/http"
 	"time"
 
@@ -47,18 +46,14 @@ var indexHTML = fmt.Sprintf(`
 `, windowWidth, windowHeight)
 
 func startServer() string {
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	// The server is closed with the window
+	url, _, err := webview.Serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(indexHTML))
+	}))
 	if err != nil {
 		log.Fatal(err)
 	}
-	go func() {
-		defer ln.Close()
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(indexHTML))
-		})
-		log.Fatal(http.Serve(ln, nil))
-	}()
-	return "http://" + ln.Addr().String()
+	return url
 }
 
 var (
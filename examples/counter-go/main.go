@@ -1,9 +1,23 @@
 package main
 
 import (
+	"embed"
+
 	"github.com/zserge/webview"
 )
 
+//go:embed js
+var assets embed.FS
+
+// mustAsset returns the content of an embedded file
+func mustAsset(name string) []byte {
+	b, err := assets.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
 // Counter is a simple example of automatic Go-to-JS data binding
 type Counter struct {
 	Value int `json:"value"`
@@ -25,12 +39,12 @@ func main() {
 	})
 	defer w.Exit()
 
-	w.Dispatch(func() {
-		// Inject controller
-		w.Bind("counter", &Counter{})
+	// Inject controller
+	w.Bind("counter", &Counter{})
 
+	w.OnReady(func() {
 		// Inject CSS
-		w.InjectCSS(string(MustAsset("js/styles.css")))
+		w.InjectCSS(string(mustAsset("js/styles.css")))
 
 		// Inject web UI framework and app UI code
 		loadUIFramework(w)
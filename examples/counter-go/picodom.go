@@ -2,8 +2,6 @@
 
 package main
 
-//go:generate go-bindata -o assets.go js/picodom/... js/styles.css
-
 import (
 	"github.com/zserge/webview"
 )
@@ -12,7 +10,7 @@ var uiFrameworkName = "Picodom"
 
 func loadUIFramework(w webview.WebView) {
 	// Inject Picodom.js
-	w.Eval(string(MustAsset("js/picodom/vendor/picodom.js")))
+	w.Eval(string(mustAsset("js/picodom/vendor/picodom.js")))
 	// Inject app code
-	w.Eval(string(MustAsset("js/picodom/app.js")))
+	w.Eval(string(mustAsset("js/picodom/app.js")))
 }
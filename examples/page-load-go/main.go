@@ -3,7 +3,6 @@ package main
 import (
 	"io/ioutil"
 	"log"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -25,18 +24,12 @@ var indexHTML = `
 `
 
 func runLocalHTTP() {
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	url, _, err := webview.Serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(indexHTML))
+	}))
 	if err != nil {
 		log.Fatal(err)
 	}
-	go func() {
-		defer ln.Close()
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(indexHTML))
-		})
-		log.Fatal(http.Serve(ln, nil))
-	}()
-	url := "http://" + ln.Addr().String()
 	w := webview.New(webview.Settings{
 		Title: "Loaded: Local HTTP Server",
 		URL:   url,
@@ -45,6 +38,21 @@ func runLocalHTTP() {
 	w.Run()
 }
 
+// runCustomScheme does not work on Windows, which has no custom schemes
+func runCustomScheme() {
+	w := webview.New(webview.Settings{
+		Title: "Loaded: Custom URI scheme",
+		URL:   "app://localhost/",
+		Schemes: map[string]http.Handler{
+			"app": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(indexHTML))
+			}),
+		},
+	})
+	defer w.Exit()
+	w.Run()
+}
+
 func runLocalFile() {
 	dir, err := ioutil.TempDir("", "webview")
 	if err != nil {
@@ -83,7 +91,7 @@ func runInjectJS() {
 		URL:   `data:text/html,<html><script type="text/javascript"></script></html>`,
 	})
 	defer w.Exit()
-	w.Dispatch(func() {
+	w.OnReady(func() {
 		w.Eval(`document.body.innerHTML = "<h1>Hello, world</h1>";`)
 	})
 	w.Run()
@@ -91,6 +99,7 @@ func runInjectJS() {
 
 func main() {
 	runLocalHTTP()
+	//runCustomScheme()
 	//runLocalFile()
 	//runDataURL()
 	//runInjectJS()
@@ -1,48 +1,17 @@
 package main
 
-//go:generate go get -u github.com/jteeuwen/go-bindata/...
-//go:generate go-bindata -pkg $GOPACKAGE -o assets.go -prefix assets/ assets/
-
 import (
-	"bytes"
+	"embed"
 	"encoding/json"
 	"fmt"
-	"io"
+	"io/fs"
 	"log"
-	"mime"
-	"net"
-	"net/http"
-	"path/filepath"
 
 	"github.com/zserge/webview"
 )
 
-func startServer() string {
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		log.Fatal(err)
-	}
-	go func() {
-		defer ln.Close()
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			path := r.URL.Path
-			if len(path) > 0 && path[0] == '/' {
-				path = path[1:]
-			}
-			if path == "" {
-				path = "index.html"
-			}
-			if bs, err := Asset(path); err != nil {
-				w.WriteHeader(http.StatusNotFound)
-			} else {
-				w.Header().Add("Content-Type", mime.TypeByExtension(filepath.Ext(path)))
-				io.Copy(w, bytes.NewBuffer(bs))
-			}
-		})
-		log.Fatal(http.Serve(ln, nil))
-	}()
-	return "http://" + ln.Addr().String()
-}
+//go:embed assets
+var assets embed.FS
 
 // Task is a data model type, it contains information about task name and status (done/not done).
 type Task struct {
@@ -112,12 +81,15 @@ func handleRPC(w webview.WebView, data string) {
 }
 
 func main() {
-	url := startServer()
+	root, err := fs.Sub(assets, "assets")
+	if err != nil {
+		log.Fatal(err)
+	}
 	w := webview.New(webview.Settings{
 		Width:  320,
 		Height: 480,
 		Title:  "Todo App",
-		URL:    url,
+		Assets: root,
 		ExternalInvokeCallback: handleRPC,
 	})
 	defer w.Exit()
@@ -2,7 +2,6 @@ package main
 
 import (
 	"log"
-	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -45,18 +44,14 @@ var indexHTML = `
 `
 
 func startServer() string {
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	// The server is closed with the window
+	url, _, err := webview.Serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(indexHTML))
+	}))
 	if err != nil {
 		log.Fatal(err)
 	}
-	go func() {
-		defer ln.Close()
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(indexHTML))
-		})
-		log.Fatal(http.Serve(ln, nil))
-	}()
-	return "http://" + ln.Addr().String()
+	return url
 }
 
 func handleRPC(w webview.WebView, data string) {
@@ -12,12 +12,12 @@ package webview
 
 /*
 #cgo linux openbsd freebsd CFLAGS: -DWEBVIEW_GTK=1
-#cgo linux openbsd freebsd pkg-config: gtk+-3.0 webkit2gtk-4.0
+#cgo linux openbsd freebsd pkg-config: gtk+-3.0 gio-unix-2.0 webkit2gtk-4.0
 
 #cgo windows CFLAGS: -DWEBVIEW_WINAPI=1
 #cgo windows LDFLAGS: -lole32 -lcomctl32 -loleaut32 -luuid -lgdi32
 
-#cgo darwin CFLAGS: -DWEBVIEW_COCOA=1 -DOBJC_OLD_DISPATCH_PROTOTYPES=1
+#cgo darwin CFLAGS: -DWEBVIEW_COCOA=1 -DOBJC_OLD_DISPATCH_PROTOTYPES=1 -fblocks
 #cgo darwin LDFLAGS: -framework WebKit
 
 #include <stdlib.h>
@@ -27,14 +27,42 @@ package webview
 #include "webview.h"
 
 extern void _webviewExternalInvokeCallback(void *, void *);
+extern void _webviewReadyCallback(void *);
+extern int _webviewNavigationCallback(void *, char *, int, int);
+static inline enum webview_navigation_decision _webview_navigation_cb(
+		struct webview *w, const char *url, enum webview_navigation_type type,
+		int new_window) {
+	return (enum webview_navigation_decision) _webviewNavigationCallback(
+		(void *)w, (char *)url, (int)type, new_window);
+}
+extern void _webviewLoadCallback(void *, int, char *, char *, double);
+static inline void _webview_load_cb(struct webview *w,
+		enum webview_load_event event, const char *url, const char *err,
+		double progress) {
+	_webviewLoadCallback((void *)w, (int)event, (char *)url, (char *)err,
+		progress);
+}
+extern void _webviewSchemeCallback(void *, void *);
+static inline void _webview_scheme_cb(struct webview *w,
+		struct webview_scheme_task *task) {
+	_webviewSchemeCallback((void *)w, (void *)task);
+}
 
 static inline void CgoWebViewFree(void *w) {
+	const char **schemes = ((struct webview *)w)->schemes;
+	if (schemes != NULL) {
+		const char **scheme;
+		for (scheme = schemes; *scheme != NULL; scheme++) {
+			free((void *)*scheme);
+		}
+		free((void *)schemes);
+	}
 	free((void *)((struct webview *)w)->title);
 	free((void *)((struct webview *)w)->url);
 	free(w);
 }
 
-static inline void *CgoWebViewCreate(int width, int height, char *title, char *url, int resizable, int debug) {
+static inline void *CgoWebViewCreate(int width, int height, char *title, char *url, int resizable, int debug, char **schemes) {
 	struct webview *w = (struct webview *) calloc(1, sizeof(*w));
 	w->width = width;
 	w->height = height;
@@ -43,6 +71,11 @@ static inline void *CgoWebViewCreate(int width, int height, char *title, char *u
 	w->resizable = resizable;
 	w->debug = debug;
 	w->external_invoke_cb = (webview_external_invoke_cb_t) _webviewExternalInvokeCallback;
+	w->ready_cb = (webview_ready_cb_t) _webviewReadyCallback;
+	w->navigation_cb = _webview_navigation_cb;
+	w->load_cb = _webview_load_cb;
+	w->schemes = (const char **)schemes;
+	w->scheme_cb = _webview_scheme_cb;
 	if (webview_init(w) != 0) {
 		CgoWebViewFree(w);
 		return NULL;
@@ -84,10 +117,91 @@ static inline int CgoWebViewEval(void *w, char *js) {
 	return webview_eval((struct webview *)w, js);
 }
 
+extern void _webviewEvalResultCallback(uintptr_t, char *, char *);
+static inline void _webview_eval_result_cb(struct webview *w, void *arg,
+		const char *result, const char *err) {
+	_webviewEvalResultCallback((uintptr_t)arg, (char *)result, (char *)err);
+}
+static inline int CgoWebViewEvalResult(void *w, char *js, uintptr_t arg) {
+	return webview_eval_result((struct webview *)w, js, _webview_eval_result_cb, (void *)arg);
+}
+
+static inline int CgoWebViewAddInitScript(void *w, char *js, int all_frames) {
+	return webview_add_init_script((struct webview *)w, js, all_frames);
+}
+
+static inline void CgoWebViewClearInitScripts(void *w) {
+	webview_clear_init_scripts((struct webview *)w);
+}
+
+static inline int CgoWebViewAddStyleSheet(void *w, char *css) {
+	return webview_add_style_sheet((struct webview *)w, css);
+}
+
+static inline int CgoWebViewClearStyleSheets(void *w) {
+	return webview_clear_style_sheets((struct webview *)w);
+}
+
+static inline void CgoWebViewNavigate(void *w, char *url) {
+	webview_navigate((struct webview *)w, url);
+}
+
+static inline int CgoWebViewSetHTML(void *w, char *html, char *base_url) {
+	return webview_set_html((struct webview *)w, html, base_url);
+}
+
+static inline void CgoWebViewReload(void *w) {
+	webview_reload((struct webview *)w);
+}
+
+static inline void CgoWebViewGoBack(void *w) {
+	webview_go_back((struct webview *)w);
+}
+
+static inline void CgoWebViewGoForward(void *w) {
+	webview_go_forward((struct webview *)w);
+}
+
+static inline void CgoWebViewStop(void *w) {
+	webview_stop((struct webview *)w);
+}
+
+static inline void CgoWebViewGetURL(void *w, char *url, size_t urlsz) {
+	webview_get_url((struct webview *)w, url, urlsz);
+}
+
 static inline void CgoWebViewInjectCSS(void *w, char *css) {
 	webview_inject_css((struct webview *)w, css);
 }
 
+static inline const char *CgoSchemeTaskURL(void *task) {
+	return webview_scheme_task_url((struct webview_scheme_task *)task);
+}
+
+static inline const char *CgoSchemeTaskMethod(void *task) {
+	return webview_scheme_task_method((struct webview_scheme_task *)task);
+}
+
+static inline char *CgoSchemeTaskHeaders(void *task) {
+	return webview_scheme_task_headers((struct webview_scheme_task *)task);
+}
+
+static inline int CgoSchemeTaskRead(void *task, void *buf, size_t sz) {
+	return webview_scheme_task_read((struct webview_scheme_task *)task, (char *)buf, sz);
+}
+
+static inline int CgoSchemeTaskRespond(void *task, int status, char *headers) {
+	return webview_scheme_task_respond((struct webview_scheme_task *)task, status, headers);
+}
+
+static inline int CgoSchemeTaskWrite(void *task, void *buf, size_t sz) {
+	return webview_scheme_task_write((struct webview_scheme_task *)task, (const char *)buf, sz);
+}
+
+static inline void CgoSchemeTaskFinish(void *task, char *err) {
+	webview_scheme_task_finish((struct webview_scheme_task *)task, err);
+}
+
 extern void _webviewDispatchGoCallback(void *);
 static inline void _webview_dispatch_cb(struct webview *w, void *arg) {
 	_webviewDispatchGoCallback(arg);
@@ -99,14 +213,29 @@ static inline void CgoWebViewDispatch(void *w, uintptr_t arg) {
 import "C"
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"io/fs"
 	"log"
+	"mime"
+	"net"
+	"net/http"
+	"path"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 	"unicode"
 	"unsafe"
 )
@@ -123,8 +252,12 @@ func init() {
 // Window appearance can be customized using title, width, height and resizable parameters.
 // URL must be provided and can user either a http or https protocol, or be a
 // local file:// URL. On some platforms "data:" URLs are also supported
-// (Linux/MacOS).
+// (Linux/MacOS). A server started with Serve() for the URL is closed when the
+// window is closed.
 func Open(title, url string, w, h int, resizable bool) error {
+	if s := takeServer(url); s != nil {
+		defer s.Close()
+	}
 	titleStr := C.CString(title)
 	defer C.free(unsafe.Pointer(titleStr))
 	urlStr := C.CString(url)
@@ -181,6 +314,120 @@ type Settings struct {
 	Debug bool
 	// A callback that is executed when JavaScript calls "window.external.invoke()"
 	ExternalInvokeCallback ExternalInvokeCallbackFunc
+	// A callback that is executed when a bound method can not be called, e.g.
+	// because the arguments passed from JavaScript can not be decoded (see
+	// ArgumentError). The error is also used to reject the JavaScript promise.
+	// If nil, errors are logged.
+	CallErrorHandler func(err error)
+	// A callback that is executed when a bound method, a function passed to
+	// Dispatch(), EvalAsync(), OnReady() or OnLoad(), ExternalInvokeCallback,
+	// NavigationPolicy or the handler of a scheme panics. The panic is recovered so that it does not
+	// crash the app. If nil, panics are logged along with the stack trace.
+	PanicHandler func(err *PanicError)
+	// A callback that decides whether a page is loaded, including the
+	// initial URL, links clicked by the user and requests to open a new
+	// window. The page is loaded if nil. Panics block the navigation. Not
+	// supported on Windows, where New() fails if it is set, rather than
+	// loading pages the policy would block.
+	NavigationPolicy func(req NavigationRequest) NavigationDecision
+	// Handlers of custom URI schemes, e.g. "app", so that pages and assets
+	// can be loaded from URLs like "app://localhost/index.html" without
+	// opening a local port. Every request is served on a goroutine of its
+	// own and the response is streamed to the page. The requests still
+	// pending on Exit() fail and the contexts of their handlers are
+	// cancelled. Not supported on Windows, where New() fails if it is set,
+	// use Serve() there instead.
+	Schemes map[string]http.Handler
+	// A file system, e.g. an embed.FS, served as the root of the app, see
+	// AssetHandler(). If URL is empty or a path, e.g. "/#/settings", the page
+	// is loaded from the assets. They are served from the "app" scheme, or
+	// from a local server started with Serve() on Windows, so New() fails if
+	// Schemes has an "app" handler too.
+	Assets fs.FS
+	// Options of the Assets handler
+	AssetOptions AssetOptions
+	// Maximum number of bound method calls running concurrently off the main
+	// thread, see BindSerial() and BindConcurrent(). Defaults to the number of
+	// CPUs.
+	Workers int
+}
+
+// NavigationType is an enumeration of the causes of a navigation
+type NavigationType int
+
+const (
+	// NavigationOther is any other navigation, e.g. Navigate() or the
+	// initial URL
+	NavigationOther NavigationType = iota
+	// NavigationLink is a click on a link
+	NavigationLink
+	// NavigationFormSubmit is a form submission
+	NavigationFormSubmit
+	// NavigationBackForward is a navigation through the history
+	NavigationBackForward
+	// NavigationReload is a reload of the page
+	NavigationReload
+	// NavigationFormResubmit is a form submitted again, e.g. on reload
+	NavigationFormResubmit
+)
+
+// NavigationRequest describes a navigation passed to
+// Settings.NavigationPolicy.
+type NavigationRequest struct {
+	// URL is the URL of the page to load
+	URL string
+	// Type is the cause of the navigation
+	Type NavigationType
+	// NewWindow is true if the page requests to open a new window, e.g. a
+	// link with target="_blank"
+	NewWindow bool
+}
+
+// NavigationDecision is an enumeration of the decisions of
+// Settings.NavigationPolicy
+type NavigationDecision int
+
+const (
+	// NavigationAllow loads the page in the webview
+	NavigationAllow NavigationDecision = iota
+	// NavigationBlock does not load the page
+	NavigationBlock
+	// NavigationOpenExternal opens the URL in the system browser instead
+	NavigationOpenExternal
+)
+
+// LoadEventType is an enumeration of the steps of a page load, see OnLoad()
+type LoadEventType int
+
+const (
+	// LoadStarted is sent when the webview starts loading a page
+	LoadStarted LoadEventType = iota
+	// LoadRedirected is sent when the server redirects to another URL (not
+	// supported on Windows)
+	LoadRedirected
+	// LoadCommitted is sent when the webview starts receiving the page
+	LoadCommitted
+	// LoadFinished is sent when the page has finished loading
+	LoadFinished
+	// LoadFailed is sent when the page can not be loaded, e.g. because the
+	// server is not reachable (not supported on Windows). It ends the load:
+	// LoadFinished is not sent and the OnReady() functions are not called
+	// for the error page that may be shown instead.
+	LoadFailed
+	// LoadProgress is sent when the estimated load progress changes (not
+	// supported on Windows)
+	LoadProgress
+)
+
+// LoadEvent describes a step of a page load
+type LoadEvent struct {
+	Type LoadEventType
+	// URL is the URL of the page, or the URL that failed to load
+	URL string
+	// Err is the reason of a LoadFailed event, nil otherwise
+	Err error
+	// Progress is the estimated load progress between 0 and 1
+	Progress float64
 }
 
 // WebView is an interface that wraps the basic methods for controlling the UI
@@ -189,7 +436,10 @@ type WebView interface {
 	// Run() starts the main UI loop until the user closes the webview window or
 	// Terminate() is called.
 	Run()
-	// Loop() runs a single iteration of the main UI.
+	// Loop() runs a single iteration of the main UI. It returns false once the
+	// window is closed or Terminate() is called. The contexts of the bound
+	// methods are cancelled then, and the servers attached to the window are
+	// closed, see Serve().
 	Loop(blocking bool) bool
 	// SetTitle() changes window title. This method must be called from the main
 	// thread only. See Dispatch() for more details.
@@ -200,13 +450,62 @@ type WebView interface {
 	// SetColor() changes window background color. This method must be called from
 	// the main thread only. See Dispatch() for more details.
 	SetColor(r, g, b, a uint8)
-	// Eval() evaluates an arbitrary JS code inside the webview. This method must
-	// be called from the main thread only. See Dispatch() for more details.
+	// Navigate() loads the given URL. As with Settings.URL, it may be a
+	// http(s), file:// or data: URL. This method must be called from the main
+	// thread only.
+	Navigate(url string)
+	// SetHTML() loads the given HTML document. Relative URLs in the document
+	// are resolved against baseURL, if not empty (not supported on Windows).
+	// This method must be called from the main thread only.
+	SetHTML(html, baseURL string) error
+	// Reload() reloads the current page. This method must be called from the
+	// main thread only.
+	Reload()
+	// GoBack() and GoForward() navigate through the history of the webview.
+	// These methods must be called from the main thread only.
+	GoBack()
+	GoForward()
+	// Stop() stops loading the current page. This method must be called from
+	// the main thread only.
+	Stop()
+	// URL() returns the URL of the current page. This method must be called
+	// from the main thread only.
+	URL() string
+	// Eval() evaluates an arbitrary JS code inside the webview without waiting
+	// for it to complete. This method must be called from the main thread
+	// only. See Dispatch() for more details.
 	Eval(js string) error
+	// EvalAsync() starts evaluating a JavaScript expression inside the webview
+	// and returns immediately, without processing other events while the
+	// expression is evaluated. Once the evaluation is complete, done is called
+	// on the main thread with the JSON-encoded value of the expression, or the
+	// error, see EvalResult(). On Linux, while a page is loading, the
+	// expression waits for the page, and fails with the error of the load if
+	// the page can not be loaded. This method must be called from the main
+	// thread only.
+	EvalAsync(js string, done func(result json.RawMessage, err error))
+	// EvalResult() evaluates a JavaScript expression inside the webview and
+	// decodes its JSON-encoded value into out, unless out is nil. Values that
+	// have no JSON representation, e.g. undefined or functions, are null. If the
+	// expression throws, the exception is returned as a *JSError. If the
+	// value is a promise, it waits for the promise to settle, and a rejection
+	// is returned as a *JSError too. It blocks until the result is available
+	// or the context is done, so it must not be called from the main thread.
+	EvalResult(ctx context.Context, js string, out interface{}) error
+	// CallJS() calls the global JavaScript function with the given name, which
+	// may be a dot-separated path, e.g. "app.render", with the JSON-encoded
+	// arguments and returns the JSON-encoded result. Like EvalResult(), it
+	// must not be called from the main thread.
+	CallJS(ctx context.Context, fn string, args ...interface{}) (json.RawMessage, error)
 	// InjectJS() injects an arbitrary block of CSS code using the JS API. This
 	// method must be called from the main thread only. See Dispatch() for more
 	// details.
 	InjectCSS(css string)
+	// AddStyleSheet() adds a style sheet that applies to the current page and
+	// to every page loaded later. The returned handle removes or replaces the
+	// style sheet. This method and the methods of the handle must be called
+	// from the main thread only.
+	AddStyleSheet(css string) (StyleHandle, error)
 	// Dialog() opens a system dialog of the given type and title. String
 	// argument can be provided for certain dialogs, such as alert boxes. For
 	// alert boxes argument is a message inside the dialog box.
@@ -224,10 +523,78 @@ type WebView interface {
 	// Bind() registers a binding between a given value and a JavaScript object with the
 	// given name.  A value must be a struct or a struct pointer. All methods are
 	// available under their camel-case names, starting with a lower-case letter,
-	// e.g. "FooBar" becomes "fooBar" in JavaScript.
+	// e.g. "FooBar" becomes "fooBar" and "HTTPGet" becomes "httpGet" in
+	// JavaScript. Use MethodLister, JSNamer, BindExclude() or BindNameFunc() to
+	// control which methods are exposed and under which names.
+	// Trailing pointer arguments of a method are optional in JavaScript and are
+	// nil if omitted, variadic arguments are passed as the remaining
+	// JavaScript arguments. JavaScript functions can be passed to arguments of
+	// type JSFunc. Methods that return a channel return an async iterable in
+	// JavaScript, which receives the values from the channel one at a time,
+	// and which can also be awaited for the iterator.
+	// When JavaScript stops iterating, the context passed to the method is
+	// cancelled and the remaining values are discarded. Every method returns a
+	// Promise that resolves with the JSON-encoded value returned by the Go
+	// method: null if the method returns nothing, the value itself if it
+	// returns a single value, or an array if it returns several values. If the
+	// last value returned by the Go method is a non-nil error, the promise is
+	// rejected with an Error object instead, see Error, ErrorCoder and
+	// ErrorDataProvider for details.
+	// If the first argument of a method is a context.Context, it is not
+	// exposed to JavaScript. The context is cancelled when the window is
+	// closed, when another page is loaded, when the timeout set with
+	// BindTimeout() elapses, or when the AbortSignal passed as an extra last
+	// argument to the JavaScript method is aborted.
+	// Methods are called on the main UI thread, use BindSerial() or
+	// BindConcurrent() to call them from the worker pool instead.
+	// The name may be a dot-separated path, e.g. "app.settings", to create the
+	// object in a nested namespace. Struct fields tagged with `webview:"name"`
+	// are exposed as nested objects with the given name, e.g. the methods of
+	// a field tagged with `webview:"files"` are available as
+	// "app.files.open()".
+	// Bindings are installed at the start of every page loaded later, see
+	// AddInitScript().
+	// Binding a value with the same name as an existing binding replaces it,
+	// the contexts of its calls in progress are cancelled and its streams are
+	// closed.
 	// Bind() returns a function that updates JavaScript object with the current
 	// Go value. You only need to call it if you change Go value asynchronously.
-	Bind(name string, v interface{}) (sync func(), err error)
+	Bind(name string, v interface{}, opts ...BindOption) (sync func(), err error)
+	// BindFunc() registers a function as a global JavaScript function with the
+	// given name, which may be a dot-separated path as well. Arguments and return values are converted the same way as
+	// for the methods of the values registered with Bind().
+	BindFunc(name string, fn interface{}, opts ...BindOption) error
+	// Emit() sends an event with the JSON-encoded payload to the JavaScript
+	// handlers registered with "webview.on(event, handler)" and removed with
+	// "webview.off(event, handler)". Events emitted before the page is ready
+	// are queued. This method may be called from any goroutine.
+	Emit(event string, payload interface{}) error
+	// AddInitScript() adds a script that is evaluated at the start of every
+	// page loaded later, before the scripts of the page, e.g. to define
+	// globals or polyfills. The script is injected into the top frame only,
+	// unless InitScriptAllFrames() is passed. On Windows, init scripts are
+	// evaluated once the page is loaded instead. This method must be called
+	// from the main thread only.
+	AddInitScript(js string, opts ...InitScriptOption) error
+	// OnReady() registers a function that is called on the main thread every
+	// time a page has finished loading, including after navigation.
+	OnReady(f func())
+	// WaitReady() blocks until the current page has finished loading, i.e.
+	// until the first page has loaded or, once another page is being loaded,
+	// until that page has, or until the context is done. It must not be
+	// called from the main thread.
+	WaitReady(ctx context.Context) error
+	// OnLoad() registers a function that is called on the main thread on
+	// every step of a page load, e.g. to show a loading indicator or an error
+	// page. LoadFinished is sent before the OnReady() functions are called.
+	OnLoad(f func(e LoadEvent))
+	// Unbind() removes the value or function registered with the given name
+	// and deletes the JavaScript object or function. The contexts of its calls
+	// in progress are cancelled and its streams are closed.
+	Unbind(name string) error
+	// Bindings() lists all values and functions registered with Bind() and
+	// BindFunc().
+	Bindings() []BindingInfo
 }
 
 // DialogType is an enumeration of all supported system dialog types
@@ -260,10 +627,50 @@ var (
 	index uintptr
 	fns   = map[uintptr]func(){}
 	cbs   = map[WebView]ExternalInvokeCallbackFunc{}
+
+	evalIndex uintptr
+	evals     = map[uintptr]func(evalResult){}
 )
 
 type webview struct {
-	w unsafe.Pointer
+	w                unsafe.Pointer
+	ctx              context.Context
+	cancel           context.CancelFunc
+	callErrorHandler func(err error)
+	panicHandler     func(err *PanicError)
+	pool             *workerPool
+	bindings         registry
+
+	mu sync.Mutex
+	// ready is true once the runtime of the page is ready, the events emitted
+	// before are queued
+	ready   bool
+	events  []string
+	onReady []func()
+	onLoad  []func(e LoadEvent)
+	loaded  chan struct{}
+	// failed is true if the page being loaded failed to load
+	failed bool
+
+	// page is incremented every time a page starts loading. awaiting holds
+	// the evaluations of the page waiting for a promise, settled the results
+	// that arrived before their evaluation was known to be pending.
+	page     int
+	awaiting map[uintptr]pendingEval
+	settled  map[uintptr]pendingEval
+
+	navigationPolicy func(req NavigationRequest) NavigationDecision
+	schemes          map[string]http.Handler
+	initScripts      []initScript
+
+	// nativeStyles is false if the platform has no user style sheets, they
+	// are emulated with style elements then
+	nativeStyles bool
+	styleSheets  []*styleSheet
+	styleSeq     int
+
+	// closers are closed on Exit() or when the loop ends, see close()
+	closers []io.Closer
 }
 
 var _ WebView = &webview{}
@@ -275,6 +682,35 @@ func boolToInt(b bool) int {
 	return 0
 }
 
+// cStringArray returns a NULL-terminated array of C strings, or nil if ss is
+// empty. The caller must free the strings and the array.
+func cStringArray(ss []string) **C.char {
+	if len(ss) == 0 {
+		return nil
+	}
+	p := C.malloc(C.size_t(len(ss)+1) * C.size_t(unsafe.Sizeof(uintptr(0))))
+	a := (*[1 << 20]*C.char)(p)[: len(ss)+1 : len(ss)+1]
+	for i, s := range ss {
+		a[i] = C.CString(s)
+	}
+	a[len(ss)] = nil
+	return (**C.char)(p)
+}
+
+// checkAssets returns an error if the assets would be served from the scheme
+// of another handler.
+func checkAssets(settings Settings) error {
+	if settings.Assets == nil {
+		return nil
+	}
+	for scheme := range settings.Schemes {
+		if strings.EqualFold(scheme, "app") {
+			return errors.New("webview: Schemes can not have an \"app\" handler when Assets are set")
+		}
+	}
+	return nil
+}
+
 // New creates and opens a new webview window using the given settings. The
 // returned object implements the WebView interface. This function returns nil
 // if a window can not be created.
@@ -288,10 +724,73 @@ func New(settings Settings) WebView {
 	if settings.Title == "" {
 		settings.Title = "WebView"
 	}
-	w := &webview{}
+	if settings.Workers <= 0 {
+		settings.Workers = runtime.NumCPU()
+	}
+	if settings.NavigationPolicy != nil && runtime.GOOS == "windows" {
+		log.Println("webview: NavigationPolicy is not supported on Windows")
+		return nil
+	}
+	if len(settings.Schemes) > 0 && runtime.GOOS == "windows" {
+		log.Println("webview: Schemes are not supported on Windows, use Serve() instead")
+		return nil
+	}
+	if err := checkAssets(settings); err != nil {
+		log.Println(err)
+		return nil
+	}
+	w := &webview{
+		callErrorHandler: settings.CallErrorHandler,
+		panicHandler:     settings.PanicHandler,
+		navigationPolicy: settings.NavigationPolicy,
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	w.pool = &workerPool{size: settings.Workers}
+	w.loaded = make(chan struct{})
+	if settings.Assets != nil {
+		h := AssetHandler(settings.Assets, settings.AssetOptions)
+		url := func(path string) string { return "app://localhost" + path }
+		if runtime.GOOS == "windows" {
+			// MSHTML has no custom schemes
+			s, err := serve(h)
+			if err != nil {
+				log.Println(err)
+				return nil
+			}
+			url = s.url
+			w.closers = append(w.closers, s)
+		} else {
+			schemes := map[string]http.Handler{"app": h}
+			for scheme, h := range settings.Schemes {
+				schemes[scheme] = h
+			}
+			settings.Schemes = schemes
+		}
+		if settings.URL == "" || strings.HasPrefix(settings.URL, "/") {
+			settings.URL = url("/" + strings.TrimPrefix(settings.URL, "/"))
+		}
+	}
+	if s := takeServer(settings.URL); s != nil {
+		w.closers = append(w.closers, s)
+	}
+	w.schemes = map[string]http.Handler{}
+	schemes := []string{}
+	for scheme, h := range settings.Schemes {
+		scheme = strings.ToLower(scheme)
+		w.schemes[scheme] = h
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
 	w.w = C.CgoWebViewCreate(C.int(settings.Width), C.int(settings.Height),
 		C.CString(settings.Title), C.CString(settings.URL),
-		C.int(boolToInt(settings.Resizable)), C.int(boolToInt(settings.Debug)))
+		C.int(boolToInt(settings.Resizable)), C.int(boolToInt(settings.Debug)),
+		cStringArray(schemes))
+	if w.w == nil {
+		w.close()
+		return nil
+	}
+	w.nativeStyles = C.CgoWebViewClearStyleSheets(w.w) == 0
+	w.updateInitScripts()
 	m.Lock()
 	if settings.ExternalInvokeCallback != nil {
 		cbs[w] = settings.ExternalInvokeCallback
@@ -307,7 +806,12 @@ func (w *webview) Loop(blocking bool) bool {
 	if blocking {
 		block = 1
 	}
-	return C.CgoWebViewLoop(w.w, block) == 0
+	if C.CgoWebViewLoop(w.w, block) != 0 {
+		// The window is closed or the loop is terminated
+		w.close()
+		return false
+	}
+	return true
 }
 
 func (w *webview) Run() {
@@ -316,14 +820,34 @@ func (w *webview) Run() {
 }
 
 func (w *webview) Exit() {
+	w.close()
 	C.CgoWebViewExit(w.w)
 }
 
+// close cancels the context of the bound methods and closes the servers
+// attached to the webview, see Serve()
+func (w *webview) close() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	for _, c := range w.closers {
+		c.Close()
+	}
+	w.closers = nil
+}
+
 func (w *webview) Dispatch(f func()) {
 	m.Lock()
 	for ; fns[index] != nil; index++ {
 	}
-	fns[index] = f
+	fns[index] = func() {
+		defer func() {
+			if r := recover(); r != nil {
+				w.handlePanic(newPanicError("Dispatch", r))
+			}
+		}()
+		f()
+	}
 	m.Unlock()
 	C.CgoWebViewDispatch(w.w, C.uintptr_t(index))
 }
@@ -355,6 +879,50 @@ func (w *webview) Dialog(dlgType DialogType, flags int, title string, arg string
 	return C.GoString(resultPtr)
 }
 
+func (w *webview) Navigate(url string) {
+	if s := takeServer(url); s != nil {
+		w.closers = append(w.closers, s)
+	}
+	p := C.CString(url)
+	defer C.free(unsafe.Pointer(p))
+	C.CgoWebViewNavigate(w.w, p)
+}
+
+func (w *webview) SetHTML(html, baseURL string) error {
+	htmlPtr := C.CString(html)
+	defer C.free(unsafe.Pointer(htmlPtr))
+	baseURLPtr := C.CString(baseURL)
+	defer C.free(unsafe.Pointer(baseURLPtr))
+	if C.CgoWebViewSetHTML(w.w, htmlPtr, baseURLPtr) != 0 {
+		return errors.New("can not load HTML")
+	}
+	return nil
+}
+
+func (w *webview) Reload() {
+	C.CgoWebViewReload(w.w)
+}
+
+func (w *webview) GoBack() {
+	C.CgoWebViewGoBack(w.w)
+}
+
+func (w *webview) GoForward() {
+	C.CgoWebViewGoForward(w.w)
+}
+
+func (w *webview) Stop() {
+	C.CgoWebViewStop(w.w)
+}
+
+func (w *webview) URL() string {
+	const maxURL = 8192
+	p := (*C.char)(C.calloc(1, maxURL))
+	defer C.free(unsafe.Pointer(p))
+	C.CgoWebViewGetURL(w.w, p, maxURL)
+	return C.GoString(p)
+}
+
 func (w *webview) Eval(js string) error {
 	p := C.CString(js)
 	defer C.free(unsafe.Pointer(p))
@@ -365,208 +933,2753 @@ func (w *webview) Eval(js string) error {
 	return nil
 }
 
-func (w *webview) InjectCSS(css string) {
-	p := C.CString(css)
-	defer C.free(unsafe.Pointer(p))
-	C.CgoWebViewInjectCSS(w.w, p)
+// evalResult is the outcome of an evaluation started by evalJS.
+type evalResult struct {
+	Result  json.RawMessage `json:"result"`
+	Error   *JSError        `json:"error"`
+	Pending bool            `json:"pending"`
+	err     error
 }
 
-func (w *webview) Terminate() {
-	C.CgoWebViewTerminate(w.w)
+// evalJSTmpl evaluates the expression in the global scope and returns its
+// JSON-encoded value or the thrown exception as a string, so that the result
+// is passed to Go the same way on all platforms. If the value is a promise or
+// another thenable, it only reports that the result is pending and the
+// settled value is sent later as an "eval" runtime message, along with the
+// nonce of the evaluation so that other scripts can't forge it.
+const evalJSTmpl = `(function() {
+	function error(e) {
+		return {
+			name: String(e && e.name || 'Error'),
+			message: String(e && e.message !== undefined ? e.message : e),
+			stack: String(e && e.stack || '')
+		};
+	}
+	function settle(msg) {
+		msg.webview = 'eval';
+		msg.id = %[2]d;
+		msg.nonce = %[3]q;
+		try {
+			window.external.invoke(JSON.stringify(msg));
+		} catch (e) {
+			window.external.invoke(JSON.stringify({webview: 'eval', id: %[2]d, nonce: %[3]q, error: error(e)}));
+		}
+	}
+	try {
+		var r = (0, eval)(%[1]s);
+		if (r && (typeof r === 'object' || typeof r === 'function') && typeof r.then === 'function') {
+			r.then(function(v) {
+				settle({result: v === undefined ? null : v});
+			}, function(e) {
+				settle({error: error(e)});
+			});
+			return JSON.stringify({pending: true});
+		}
+		return JSON.stringify({result: r === undefined ? null : r});
+	} catch (e) {
+		return JSON.stringify({error: error(e)});
+	}
+})()`
+
+func (w *webview) EvalAsync(js string, done func(result json.RawMessage, err error)) {
+	expr, _ := json.Marshal(js)
+	id, nonce := w.startEval(done)
+	p := C.CString(fmt.Sprintf(evalJSTmpl, expr, id, nonce))
+	defer C.free(unsafe.Pointer(p))
+	if C.CgoWebViewEvalResult(w.w, p, C.uintptr_t(id)) == -1 {
+		finishEval(id, "", errors.New("evaluation failed"))
+	}
 }
 
-//export _webviewDispatchGoCallback
-func _webviewDispatchGoCallback(index unsafe.Pointer) {
-	var f func()
+// startEval registers done for the result of a new evaluation and returns the
+// ID and the nonce of the evaluation. done is only called once the webview
+// reports the result with finishEval(), never before EvalAsync() returns.
+func (w *webview) startEval(done func(result json.RawMessage, err error)) (uintptr, string) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	nonce := hex.EncodeToString(b)
+	w.mu.Lock()
+	page := w.page
+	w.mu.Unlock()
 	m.Lock()
-	f = fns[uintptr(index)]
-	delete(fns, uintptr(index))
+	for evalIndex++; evals[evalIndex] != nil; evalIndex++ {
+	}
+	id := evalIndex
+	var f func(res evalResult)
+	f = func(res evalResult) {
+		if res.err == nil && res.Pending {
+			w.await(id, page, nonce, f)
+			return
+		}
+		if done == nil {
+			return
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				w.handlePanic(newPanicError("EvalAsync", r))
+			}
+		}()
+		switch {
+		case res.err != nil:
+			done(nil, res.err)
+		case res.Error != nil:
+			done(nil, res.Error)
+		case len(res.Result) == 0:
+			// undefined, functions and symbols have no JSON value
+			done(json.RawMessage("null"), nil)
+		default:
+			done(res.Result, nil)
+		}
+	}
+	evals[id] = f
 	m.Unlock()
-	f()
+	return id, nonce
 }
 
-//export _webviewExternalInvokeCallback
-func _webviewExternalInvokeCallback(w unsafe.Pointer, data unsafe.Pointer) {
+// finishEval passes the JSON-encoded result of an evaluation, or the error
+// that prevented it, to the callback of the evaluation.
+func finishEval(id uintptr, result string, err error) {
 	m.Lock()
-	var (
-		cb ExternalInvokeCallbackFunc
-		wv WebView
-	)
-	for wv, cb = range cbs {
-		if wv.(*webview).w == w {
-			break
+	f := evals[id]
+	delete(evals, id)
+	m.Unlock()
+	if f == nil {
+		return
+	}
+	r := evalResult{err: err}
+	if err == nil {
+		r.err = json.Unmarshal([]byte(result), &r)
+	}
+	f(r)
+}
+
+// pendingEval is an evaluation waiting for a promise, or the settled value of
+// a promise waiting for its evaluation.
+type pendingEval struct {
+	f     func(res evalResult)
+	nonce string
+	res   evalResult
+}
+
+// await keeps the callback of an evaluation whose value is a promise until
+// the promise is settled. The result may already have been reported by the
+// page, as both are delivered asynchronously. The evaluation fails if the
+// page it was started on is unloaded.
+func (w *webview) await(id uintptr, page int, nonce string, f func(res evalResult)) {
+	w.mu.Lock()
+	if page != w.page {
+		w.mu.Unlock()
+		f(evalResult{err: errors.New("webview: the page was unloaded")})
+		return
+	}
+	s, ok := w.settled[id]
+	delete(w.settled, id)
+	ok = ok && s.nonce == nonce
+	if !ok {
+		if w.awaiting == nil {
+			w.awaiting = map[uintptr]pendingEval{}
 		}
+		w.awaiting[id] = pendingEval{f: f, nonce: nonce}
+	}
+	w.mu.Unlock()
+	if ok {
+		f(s.res)
 	}
-	m.Unlock()
-	cb(wv, C.GoString((*C.char)(data)))
 }
 
-var bindTmpl = template.Must(template.New("").Parse(`
-if (typeof {{.Name}} === 'undefined') {
-	{{.Name}} = {};
+// settle passes the value of a settled promise to its evaluation. Values
+// without the nonce of the evaluation are ignored.
+func (w *webview) settle(id uintptr, nonce string, res evalResult) {
+	w.mu.Lock()
+	p, ok := w.awaiting[id]
+	if ok {
+		if p.nonce != nonce {
+			w.mu.Unlock()
+			return
+		}
+		delete(w.awaiting, id)
+	} else {
+		if w.settled == nil {
+			w.settled = map[uintptr]pendingEval{}
+		}
+		w.settled[id] = pendingEval{nonce: nonce, res: res}
+	}
+	w.mu.Unlock()
+	if ok {
+		p.f(res)
+	}
 }
-{{ range .Methods }}
-{{$.Name}}.{{.JSName}} = function({{.JSArgs}}) {
-	window.external.invoke(JSON.stringify({scope: "{{$.Name}}", method: "{{.Name}}", params: [{{.JSArgs}}]}));
-};
-{{ end }}
-`))
 
-type binding struct {
-	Value   interface{}
-	Name    string
-	Methods []methodInfo
+// evalJS evaluates the expression on the main thread and waits for its
+// JSON-encoded value.
+func (w *webview) evalJS(ctx context.Context, js string) (json.RawMessage, error) {
+	type result struct {
+		value json.RawMessage
+		err   error
+	}
+	ch := make(chan result, 1)
+	w.Dispatch(func() {
+		w.EvalAsync(js, func(value json.RawMessage, err error) {
+			ch <- result{value, err}
+		})
+	})
+	select {
+	case r := <-ch:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
-func newBinding(name string, v interface{}) (*binding, error) {
-	methods, err := getMethods(v)
-	if err != nil {
+func (w *webview) EvalResult(ctx context.Context, js string, out interface{}) error {
+	result, err := w.evalJS(ctx, js)
+	if err != nil || out == nil {
+		return err
+	}
+	return json.Unmarshal(result, out)
+}
+
+func (w *webview) CallJS(ctx context.Context, fn string, args ...interface{}) (json.RawMessage, error) {
+	if err := validName(fn); err != nil {
 		return nil, err
 	}
-	return &binding{Name: name, Value: v, Methods: methods}, nil
+	params := make([]string, len(args))
+	for i, arg := range args {
+		b, err := json.Marshal(arg)
+		if err != nil {
+			return nil, err
+		}
+		params[i] = string(b)
+	}
+	return w.evalJS(ctx, fn+"("+strings.Join(params, ",")+")")
 }
 
-func (b *binding) JS() (string, error) {
-	js := &bytes.Buffer{}
-	err := bindTmpl.Execute(js, b)
-	return js.String(), err
+// initScript is a script added with AddInitScript().
+type initScript struct {
+	js        string
+	allFrames bool
 }
 
-func (b *binding) Sync() (string, error) {
-	js, err := json.Marshal(b.Value)
-	if err == nil {
-		return fmt.Sprintf("%[1]s.data=%[2]s;if(%[1]s.render){%[1]s.render(%[2]s);}", b.Name, string(js)), nil
+// InitScriptOption configures a script added with AddInitScript().
+type InitScriptOption func(*initScript)
+
+// InitScriptAllFrames injects the script into all frames rather than into the
+// top frame only.
+func InitScriptAllFrames() InitScriptOption {
+	return func(s *initScript) {
+		s.allFrames = true
 	}
-	return "", err
 }
 
-func (b *binding) Call(js string) bool {
-	type rpcCall struct {
-		Scope  string        `json:"scope"`
-		Method string        `json:"method"`
-		Params []interface{} `json:"params"`
+func (w *webview) addInitScript(s initScript) error {
+	p := C.CString(s.js)
+	defer C.free(unsafe.Pointer(p))
+	if C.CgoWebViewAddInitScript(w.w, p, C.int(boolToInt(s.allFrames))) == -1 {
+		return errors.New("can not add init script")
 	}
+	return nil
+}
 
-	rpc := rpcCall{}
-	if err := json.Unmarshal([]byte(js), &rpc); err != nil {
-		return false
+func (w *webview) AddInitScript(js string, opts ...InitScriptOption) error {
+	s := initScript{js: js}
+	for _, opt := range opts {
+		opt(&s)
 	}
-	if rpc.Scope != b.Name {
-		return false
+	if err := w.addInitScript(s); err != nil {
+		return err
 	}
-	var mi *methodInfo
-	for i := 0; i < len(b.Methods); i++ {
-		if b.Methods[i].Name == rpc.Method {
-			mi = &b.Methods[i]
-			break
+	w.initScripts = append(w.initScripts, s)
+	return nil
+}
+
+// updateInitScripts replaces all init scripts with the runtime, the current
+// bindings and the scripts added with AddInitScript(), in this order.
+func (w *webview) updateInitScripts() {
+	C.CgoWebViewClearInitScripts(w.w)
+	js := runtimeJS + w.bindings.JS()
+	if !w.nativeStyles {
+		for _, s := range w.styleSheets {
+			js = js + s.JS()
 		}
 	}
-	if mi == nil {
-		return false
-	}
-	args := make([]reflect.Value, mi.Arity(), mi.Arity())
-	for i := 0; i < mi.Arity(); i++ {
-		val := reflect.ValueOf(rpc.Params[i])
-		arg := mi.Value.Type().In(i)
-		u := reflect.New(arg)
-		if b, err := json.Marshal(val.Interface()); err == nil {
-			if err = json.Unmarshal(b, u.Interface()); err == nil {
-				args[i] = reflect.Indirect(u)
-			}
-		}
-		if !args[i].IsValid() {
-			return false
+	for _, s := range append([]initScript{{js: js}}, w.initScripts...) {
+		if err := w.addInitScript(s); err != nil {
+			log.Println(err)
 		}
 	}
-	mi.Value.Call(args)
-	return true
 }
 
-type methodInfo struct {
-	Name  string
-	Value reflect.Value
+// StyleHandle is a style sheet added with AddStyleSheet().
+type StyleHandle interface {
+	// Remove() removes the style sheet from the current page and from the
+	// pages loaded later.
+	Remove() error
+	// ReplaceCSS() replaces the contents of the style sheet.
+	ReplaceCSS(css string) error
 }
 
-func (mi methodInfo) Arity() int { return mi.Value.Type().NumIn() }
+type styleSheet struct {
+	w       *webview
+	id      int
+	css     string
+	removed bool
+}
 
-func (mi methodInfo) JSName() string {
-	r := []rune(mi.Name)
-	if len(r) > 0 {
-		r[0] = unicode.ToLower(r[0])
+// JS returns the JavaScript code that adds, updates or removes the style
+// element emulating the style sheet.
+func (s *styleSheet) JS() string {
+	css := []byte("null")
+	if !s.removed {
+		css, _ = json.Marshal(s.css)
 	}
-	return string(r)
+	return fmt.Sprintf("window.webview._style(%d,%s);", s.id, css)
 }
 
-func (mi methodInfo) JSArgs() (js string) {
-	for i := 0; i < mi.Arity(); i++ {
-		if i > 0 {
-			js = js + ","
+func (s *styleSheet) Remove() error {
+	if s.removed {
+		return errors.New("style sheet is removed")
+	}
+	s.removed = true
+	for i, other := range s.w.styleSheets {
+		if other == s {
+			s.w.styleSheets = append(s.w.styleSheets[:i], s.w.styleSheets[i+1:]...)
+			break
 		}
-		js = js + fmt.Sprintf("a%d", i)
 	}
-	return js
+	return s.w.updateStyleSheet(s)
 }
 
-func getMethods(obj interface{}) ([]methodInfo, error) {
-	p := reflect.ValueOf(obj)
-	v := reflect.Indirect(p)
-	t := reflect.TypeOf(obj)
-	if t == nil {
-		return nil, errors.New("object can not be nil")
+func (s *styleSheet) ReplaceCSS(css string) error {
+	if s.removed {
+		return errors.New("style sheet is removed")
 	}
-	k := t.Kind()
-	if k == reflect.Ptr {
-		k = v.Type().Kind()
+	s.css = css
+	return s.w.updateStyleSheet(s)
+}
+
+func (w *webview) AddStyleSheet(css string) (StyleHandle, error) {
+	w.styleSeq++
+	s := &styleSheet{w: w, id: w.styleSeq, css: css}
+	w.styleSheets = append(w.styleSheets, s)
+	return s, w.updateStyleSheet(s)
+}
+
+// updateStyleSheet applies the change of the given style sheet. User style
+// sheets can't be replaced one by one, so all of them are added again.
+func (w *webview) updateStyleSheet(s *styleSheet) error {
+	if !w.nativeStyles {
+		w.updateInitScripts()
+		return w.Eval(runtimeJS + s.JS())
 	}
-	if k != reflect.Struct {
-		return nil, errors.New("must be a struct or a pointer to a struct")
+	C.CgoWebViewClearStyleSheets(w.w)
+	for _, sheet := range w.styleSheets {
+		p := C.CString(sheet.css)
+		r := C.CgoWebViewAddStyleSheet(w.w, p)
+		C.free(unsafe.Pointer(p))
+		if r == -1 {
+			return errors.New("can not add style sheet")
+		}
 	}
+	return nil
+}
 
-	methods := []methodInfo{}
-	for i := 0; i < t.NumMethod(); i++ {
-		method := t.Method(i)
-		if !unicode.IsUpper([]rune(method.Name)[0]) {
-			continue
+func (w *webview) InjectCSS(css string) {
+	p := C.CString(css)
+	defer C.free(unsafe.Pointer(p))
+	C.CgoWebViewInjectCSS(w.w, p)
+}
+
+func (w *webview) Terminate() {
+	C.CgoWebViewTerminate(w.w)
+}
+
+func (w *webview) handleCallError(err error) {
+	if w.callErrorHandler != nil {
+		w.callErrorHandler(err)
+	} else {
+		log.Println(err)
+	}
+}
+
+func (w *webview) handlePanic(err *PanicError) {
+	if w.panicHandler != nil {
+		w.panicHandler(err)
+	} else {
+		log.Printf("%v\n%s", err, err.Stack)
+	}
+}
+
+//export _webviewDispatchGoCallback
+func _webviewDispatchGoCallback(index unsafe.Pointer) {
+	var f func()
+	m.Lock()
+	f = fns[uintptr(index)]
+	delete(fns, uintptr(index))
+	m.Unlock()
+	f()
+}
+
+//export _webviewEvalResultCallback
+func _webviewEvalResultCallback(index C.uintptr_t, result *C.char, err *C.char) {
+	if err != nil {
+		finishEval(uintptr(index), "", errors.New(C.GoString(err)))
+	} else {
+		finishEval(uintptr(index), C.GoString(result), nil)
+	}
+}
+
+// lookupWebView returns the webview of the native window, or nil if the
+// window is not open.
+func lookupWebView(w unsafe.Pointer) *webview {
+	m.Lock()
+	defer m.Unlock()
+	for wv := range cbs {
+		if wv := wv.(*webview); wv.w == w {
+			return wv
+		}
+	}
+	return nil
+}
+
+//export _webviewReadyCallback
+func _webviewReadyCallback(w unsafe.Pointer) {
+	wv := lookupWebView(w)
+	if wv != nil {
+		wv.pageReady()
+	}
+}
+
+//export _webviewNavigationCallback
+func _webviewNavigationCallback(w unsafe.Pointer, url *C.char, typ C.int, newWindow C.int) C.int {
+	wv := lookupWebView(w)
+	if wv == nil {
+		return C.int(NavigationAllow)
+	}
+	req := NavigationRequest{URL: C.GoString(url), Type: NavigationType(typ), NewWindow: newWindow != 0}
+	return C.int(wv.navigate(req))
+}
+
+//export _webviewLoadCallback
+func _webviewLoadCallback(w unsafe.Pointer, event C.int, url *C.char, err *C.char, progress C.double) {
+	wv := lookupWebView(w)
+	if wv == nil {
+		return
+	}
+	e := LoadEvent{Type: LoadEventType(event), URL: C.GoString(url), Progress: float64(progress)}
+	if err != nil {
+		e.Err = errors.New(C.GoString(err))
+	}
+	wv.load(e)
+}
+
+// load calls the OnLoad() functions. Events emitted once a new page has
+// started loading are queued until its runtime is ready, and the calls of the
+// previous page are cancelled.
+func (w *webview) load(e LoadEvent) {
+	w.mu.Lock()
+	// Some platforms report that the error page has finished loading too
+	if e.Type == LoadFinished && w.failed {
+		w.mu.Unlock()
+		return
+	}
+	if e.Type == LoadFailed {
+		w.failed = true
+	}
+	var awaiting map[uintptr]pendingEval
+	if e.Type == LoadStarted {
+		w.page++
+		w.failed = false
+		w.ready = false
+		awaiting, w.awaiting, w.settled = w.awaiting, nil, nil
+		// WaitReady() waits for the new page
+		select {
+		case <-w.loaded:
+			w.loaded = make(chan struct{})
+		default:
+		}
+	}
+	fns := append([]func(e LoadEvent){}, w.onLoad...)
+	w.mu.Unlock()
+	if e.Type == LoadStarted {
+		// The IDs of the calls restart on the new page
+		w.bindings.Reset()
+		// The promises of the previous page are never settled
+		for _, p := range awaiting {
+			p.f(evalResult{err: errors.New("webview: the page was unloaded")})
+		}
+	}
+	for _, f := range fns {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					w.handlePanic(newPanicError("OnLoad", r))
+				}
+			}()
+			f(e)
+		}()
+	}
+}
+
+func (w *webview) OnLoad(f func(e LoadEvent)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onLoad = append(w.onLoad, f)
+}
+
+//export _webviewSchemeCallback
+func _webviewSchemeCallback(w unsafe.Pointer, task unsafe.Pointer) {
+	wv := lookupWebView(w)
+	if wv == nil {
+		(&schemeResponse{task: task}).finish(errors.New("webview: no handler"))
+		return
+	}
+	wv.serveScheme(task)
+}
+
+// serveScheme reads the request of a scheme task on the main thread and
+// serves it on a goroutine of its own, like net/http does.
+func (w *webview) serveScheme(task unsafe.Pointer) {
+	url := C.GoString(C.CgoSchemeTaskURL(task))
+	method := C.GoString(C.CgoSchemeTaskMethod(task))
+	headers := C.CgoSchemeTaskHeaders(task)
+	header := parseSchemeHeaders(C.GoString(headers))
+	C.free(unsafe.Pointer(headers))
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	resp := &schemeResponse{task: task, header: http.Header{}, cancel: cancel}
+	req, err := http.NewRequestWithContext(ctx, method, url, schemeBody{task})
+	if err != nil {
+		cancel()
+		resp.finish(err)
+		return
+	}
+	h := w.schemes[strings.ToLower(req.URL.Scheme)]
+	if h == nil {
+		cancel()
+		resp.finish(fmt.Errorf("webview: no handler for %s", url))
+		return
+	}
+	req.Header = header
+	req.RequestURI = req.URL.RequestURI()
+	req.ContentLength = -1
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		req.ContentLength = n
+	}
+	go func() {
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				w.handlePanic(newPanicError("Schemes", r))
+				resp.finish(fmt.Errorf("webview: panic in handler: %v", r))
+			}
+		}()
+		h.ServeHTTP(resp, req)
+		resp.finish(nil)
+	}()
+}
+
+// schemeBody reads the body of the request of a scheme task
+type schemeBody struct {
+	task unsafe.Pointer
+}
+
+func (b schemeBody) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n := C.CgoSchemeTaskRead(b.task, unsafe.Pointer(&p[0]), C.size_t(len(p)))
+	if n < 0 {
+		return 0, errors.New("webview: can not read the request body")
+	} else if n == 0 {
+		return 0, io.EOF
+	}
+	return int(n), nil
+}
+
+// schemeResponse is the http.ResponseWriter of a scheme task. The body is
+// not buffered, every write is sent to the page.
+type schemeResponse struct {
+	task   unsafe.Pointer
+	header http.Header
+	status int
+	cancel context.CancelFunc
+	err    error
+}
+
+func (r *schemeResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *schemeResponse) WriteHeader(status int) {
+	if r.status != 0 {
+		return
+	}
+	r.status = status
+	headers := C.CString(formatSchemeHeaders(r.header))
+	defer C.free(unsafe.Pointer(headers))
+	if C.CgoSchemeTaskRespond(r.task, C.int(status), headers) != 0 {
+		r.fail()
+	}
+}
+
+func (r *schemeResponse) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		if r.header.Get("Content-Type") == "" {
+			r.header.Set("Content-Type", http.DetectContentType(p))
+		}
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	if len(p) > 0 && C.CgoSchemeTaskWrite(r.task, unsafe.Pointer(&p[0]), C.size_t(len(p))) != 0 {
+		r.fail()
+		return 0, r.err
+	}
+	return len(p), nil
+}
+
+// Flush sends the headers if needed, the body is never buffered
+func (r *schemeResponse) Flush() {
+	r.WriteHeader(http.StatusOK)
+}
+
+// fail cancels the request once the page no longer reads the response
+func (r *schemeResponse) fail() {
+	r.err = errors.New("webview: the response is closed")
+	r.cancel()
+}
+
+// finish ends the response, or fails the request if err is not nil and no
+// response has been sent yet.
+func (r *schemeResponse) finish(err error) {
+	if err == nil {
+		err = r.err
+	}
+	if err == nil && r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	var cerr *C.char
+	if err != nil {
+		cerr = C.CString(err.Error())
+		defer C.free(unsafe.Pointer(cerr))
+	}
+	C.CgoSchemeTaskFinish(r.task, cerr)
+}
+
+// parseSchemeHeaders parses the "Name: value" lines of the headers of a scheme
+// task.
+func parseSchemeHeaders(s string) http.Header {
+	h := http.Header{}
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.Index(line, ": "); i > 0 {
+			h.Add(line[:i], line[i+2:])
+		}
+	}
+	return h
+}
+
+// schemeHeaderReplacer removes line breaks from header names and values
+var schemeHeaderReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// formatSchemeHeaders formats the headers as sorted "Name: value" lines,
+// repeated headers are joined by commas.
+func formatSchemeHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b := &strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(b, "%s: %s\n", schemeHeaderReplacer.Replace(name),
+			schemeHeaderReplacer.Replace(strings.Join(h[name], ", ")))
+	}
+	return b.String()
+}
+
+// AssetOptions control how AssetHandler() serves a file system
+type AssetOptions struct {
+	// Index is the file served for a directory, "index.html" by default
+	Index string
+	// Fallback is the file served for a path without a file extension that
+	// does not exist, e.g. "index.html" for a single-page app that handles
+	// the history itself. A 404 error is returned if empty.
+	Fallback string
+	// ContentType returns the MIME type of a file. If nil or if it returns
+	// an empty string, the type is guessed from the file extension, or from
+	// the content of the file.
+	ContentType func(name string) string
+	// CacheControl is the Cache-Control header of every response, e.g.
+	// "no-cache" during development. No header is sent if empty.
+	CacheControl string
+}
+
+// assetTypes are the MIME types of the common files of web apps, which may be
+// missing or wrong in the MIME database of the system, e.g. on Windows.
+var assetTypes = map[string]string{
+	".css":  "text/css; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".js":   "text/javascript; charset=utf-8",
+	".json": "application/json",
+	".mjs":  "text/javascript; charset=utf-8",
+	".svg":  "image/svg+xml",
+	".wasm": "application/wasm",
+}
+
+type assetHandler struct {
+	fsys fs.FS
+	opts AssetOptions
+}
+
+// AssetHandler returns a handler that serves the files of fsys, e.g. an
+// embed.FS, to be used with Settings.Schemes or Serve(). Settings.Assets uses
+// it to serve the app.
+func AssetHandler(fsys fs.FS, opts AssetOptions) http.Handler {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	return &assetHandler{fsys: fsys, opts: opts}
+}
+
+func (h *assetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+	f, info, file, err := h.open(name)
+	if errors.Is(err, fs.ErrNotExist) && h.opts.Fallback != "" && path.Ext(name) == "" {
+		f, info, file, err = h.open(h.opts.Fallback)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	if ctype := h.contentType(file); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	if h.opts.CacheControl != "" {
+		w.Header().Set("Cache-Control", h.opts.CacheControl)
+	}
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, file, info.ModTime(), rs)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	if r.Method != http.MethodHead {
+		io.Copy(w, f)
+	}
+}
+
+// open opens a file, or the index file of a directory, and returns the name of
+// the file.
+func (h *assetHandler) open(name string) (fs.File, fs.FileInfo, string, error) {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		return nil, nil, name, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.IsDir() {
+		f.Close()
+		name = path.Join(name, h.opts.Index)
+		if f, err = h.fsys.Open(name); err != nil {
+			return nil, nil, name, err
+		}
+		info, err = f.Stat()
+		if err == nil && info.IsDir() {
+			err = fs.ErrNotExist
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, name, err
+	}
+	return f, info, name, nil
+}
+
+// contentType returns the MIME type of a file, or an empty string to detect
+// it from the content.
+func (h *assetHandler) contentType(name string) string {
+	if h.opts.ContentType != nil {
+		if ctype := h.opts.ContentType(name); ctype != "" {
+			return ctype
+		}
+	}
+	ext := strings.ToLower(path.Ext(name))
+	if ctype, ok := assetTypes[ext]; ok {
+		return ctype
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// tokenName is the name of the query parameter that carries the token of a
+// server started with Serve(), see server.cookie()
+const tokenName = "webview_token"
+
+var (
+	serversMu sync.Mutex
+	// servers are the servers started with Serve() that are not attached to
+	// a window yet, by address
+	servers = map[string]*server{}
+)
+
+// server is a loopback server that only serves the requests with its token
+type server struct {
+	srv   *http.Server
+	h     http.Handler
+	addr  string
+	token string
+}
+
+// Serve serves h on an ephemeral port of the loopback interface and returns
+// the URL to load in the webview. Other local processes can not use the
+// server: the URL carries a random token generated for every server, which
+// the first request exchanges for a cookie, and requests without the token
+// are rejected with a 403 error. A server is attached to the window that
+// loads its URL, with Settings.URL, Navigate() or Open(), and it is closed
+// when the loop of the window ends, on Exit() or when Open() returns. A
+// server whose URL is not loaded by any window must be closed by closer.
+func Serve(h http.Handler) (url string, closer io.Closer, err error) {
+	s, err := serve(h)
+	if err != nil {
+		return "", nil, err
+	}
+	serversMu.Lock()
+	servers[s.addr] = s
+	serversMu.Unlock()
+	return s.url("/"), s, nil
+}
+
+func serve(h http.Handler) (*server, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &server{h: h, addr: ln.Addr().String(), token: hex.EncodeToString(token)}
+	s.srv = &http.Server{Handler: s}
+	go s.srv.Serve(ln)
+	return s, nil
+}
+
+// takeServer returns the server started with Serve() for the URL, if any, so
+// that it is closed with the window.
+func takeServer(rawurl string) *server {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	for addr, s := range servers {
+		if strings.HasPrefix(rawurl, "http://"+addr+"/") {
+			delete(servers, addr)
+			return s
+		}
+	}
+	return nil
+}
+
+// url returns the URL of a path with the token, e.g. "/#/settings"
+func (s *server) url(path string) string {
+	fragment := ""
+	if i := strings.Index(path, "#"); i >= 0 {
+		path, fragment = path[:i], path[i:]
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return "http://" + s.addr + path + sep + tokenName + "=" + s.token + fragment
+}
+
+// cookie returns the name of the cookie that carries the token. Cookies are
+// shared by all the ports of a host, so every server has a cookie of its own.
+func (s *server) cookie() string {
+	_, port, _ := net.SplitHostPort(s.addr)
+	return tokenName + "_" + port
+}
+
+func (s *server) valid(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(s.cookie()); err == nil && s.valid(c.Value) {
+		s.h.ServeHTTP(w, r)
+		return
+	}
+	q := r.URL.Query()
+	if !s.valid(q.Get(tokenName)) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	// Exchange the token for a cookie and reload the page without it
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookie(),
+		Value:    s.token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	q.Del(tokenName)
+	u := *r.URL
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.RequestURI(), http.StatusFound)
+}
+
+// Close closes the server and its connections
+func (s *server) Close() error {
+	serversMu.Lock()
+	if servers[s.addr] == s {
+		delete(servers, s.addr)
+	}
+	serversMu.Unlock()
+	return s.srv.Close()
+}
+
+// navigate asks the navigation policy whether the page is loaded.
+func (w *webview) navigate(req NavigationRequest) (decision NavigationDecision) {
+	if w.navigationPolicy == nil {
+		return NavigationAllow
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			w.handlePanic(newPanicError("NavigationPolicy", r))
+			decision = NavigationBlock
+		}
+	}()
+	return w.navigationPolicy(req)
+}
+
+//export _webviewExternalInvokeCallback
+func _webviewExternalInvokeCallback(w unsafe.Pointer, data unsafe.Pointer) {
+	wv := lookupWebView(w)
+	if wv == nil {
+		return
+	}
+	m.Lock()
+	cb := cbs[wv]
+	m.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			wv.handlePanic(newPanicError("ExternalInvokeCallback", r))
+		}
+	}()
+	s := C.GoString((*C.char)(data))
+	if !wv.runtimeCall(s) && !wv.bindings.Call(s) {
+		cb(wv, s)
+	}
+}
+
+// readyJS tells Go that the runtime is ready, even if it was installed before.
+const readyJS = "window.external.invoke(JSON.stringify({webview: 'ready'}));"
+
+// pageReady is called on the main thread when a page has finished loading. The
+// runtime and the bindings are installed by the init scripts, only the data
+// of the bindings is updated before the OnReady() functions are called.
+func (w *webview) pageReady() {
+	fns, ok := w.loadFinished()
+	if !ok {
+		return
+	}
+	w.Eval(w.bindings.Sync() + readyJS)
+	for _, f := range fns {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					w.handlePanic(newPanicError("OnReady", r))
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+// loadFinished releases the WaitReady() calls of the page and returns the
+// OnReady() functions. It returns false if the page failed to load.
+func (w *webview) loadFinished() ([]func(), bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed {
+		return nil, false
+	}
+	w.ready = false
+	select {
+	case <-w.loaded:
+	default:
+		close(w.loaded)
+	}
+	return append([]func(){}, w.onReady...), true
+}
+
+func (w *webview) OnReady(f func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onReady = append(w.onReady, f)
+}
+
+func (w *webview) WaitReady(ctx context.Context) error {
+	w.mu.Lock()
+	loaded := w.loaded
+	w.mu.Unlock()
+	select {
+	case <-loaded:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runtimeCall handles the messages sent by the JavaScript runtime itself. It
+// returns false if the message is not one of them.
+func (w *webview) runtimeCall(data string) bool {
+	var msg struct {
+		Webview string  `json:"webview"`
+		ID      uintptr `json:"id"`
+		Nonce   string  `json:"nonce"`
+		evalResult
+	}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil || msg.Webview == "" {
+		return false
+	}
+	switch msg.Webview {
+	case "eval":
+		msg.Pending = false
+		w.settle(msg.ID, msg.Nonce, msg.evalResult)
+	case "ready":
+		for _, js := range w.runtimeReady() {
+			w.Eval(js)
+		}
+	}
+	return true
+}
+
+// runtimeReady marks the runtime of the page as ready and returns the events
+// queued until then, in the order they were emitted.
+func (w *webview) runtimeReady() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ready = true
+	events := w.events
+	w.events = nil
+	return events
+}
+
+func (w *webview) Emit(event string, payload interface{}) error {
+	e, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	js := fmt.Sprintf("window.webview._emit(%s,%s);", string(e), string(p))
+	// The init scripts install the runtime, which reports back when the page
+	// is ready
+	w.mu.Lock()
+	ready := w.ready
+	if !ready {
+		w.events = append(w.events, js)
+	}
+	w.mu.Unlock()
+	if ready {
+		w.Dispatch(func() { w.Eval(js) })
+	}
+	return nil
+}
+
+// runtimeJS is the JavaScript runtime shared by all bindings. It keeps track
+// of pending calls and settles their promises once Go replies, and dispatches
+// the events emitted by Go. Once the document is loaded it notifies Go that
+// the page is ready.
+const runtimeJS = `
+(function() {
+	if (window.webview && window.webview._call) {
+		return;
+	}
+	var webview = window.webview = window.webview || {};
+	var pending = {};
+	var seq = 0;
+	var callbacks = {};
+	var callbackSeq = 0;
+	webview._ns = function(name) {
+		var o = window;
+		var path = name ? name.split('.') : [];
+		for (var i = 0; i < path.length; i++) {
+			if (typeof o[path[i]] === 'undefined') {
+				o[path[i]] = {};
+			}
+			o = o[path[i]];
+		}
+		return o;
+	};
+	var abortError = function() {
+		var e = new Error('The operation was aborted.');
+		e.name = 'AbortError';
+		return e;
+	};
+	webview._unbind = function(name) {
+		var i = name.lastIndexOf('.');
+		var o = webview._ns(name.substring(0, i > 0 ? i : 0));
+		delete o[name.substring(i + 1)];
+	};
+	// promise creates a Promise, or a minimal thenable on engines that have
+	// no promises, e.g. MSHTML, so that bound methods can be called there too
+	var promise = function(executor) {
+		if (typeof Promise !== 'undefined') {
+			return new Promise(executor);
+		}
+		var state = 0, value, handlers = [];
+		var settle = function(s, v) {
+			if (state === 0) {
+				state = s;
+				value = v;
+				for (var i = 0; i < handlers.length; i++) {
+					run(handlers[i]);
+				}
+				handlers = null;
+			}
+		};
+		var resolve = function(v) {
+			if (v && (typeof v === 'object' || typeof v === 'function') && typeof v.then === 'function') {
+				v.then(resolve, function(e) { settle(2, e); });
+			} else {
+				settle(1, v);
+			}
+		};
+		var run = function(h) {
+			setTimeout(function() {
+				var f = state === 1 ? h.fulfilled : h.rejected;
+				if (typeof f !== 'function') {
+					(state === 1 ? h.resolve : h.reject)(value);
+					return;
+				}
+				try {
+					h.resolve(f(value));
+				} catch (e) {
+					h.reject(e);
+				}
+			}, 0);
+		};
+		var p = {
+			then: function(fulfilled, rejected) {
+				return promise(function(resolve, reject) {
+					var h = {fulfilled: fulfilled, rejected: rejected, resolve: resolve, reject: reject};
+					if (state === 0) {
+						handlers.push(h);
+					} else {
+						run(h);
+					}
+				});
+			},
+			'catch': function(rejected) {
+				return p.then(null, rejected);
+			}
+		};
+		try {
+			executor(resolve, function(e) { settle(2, e); });
+		} catch (e) {
+			settle(2, e);
+		}
+		return p;
+	};
+	var resolved = function(value) {
+		return promise(function(resolve) {
+			resolve(value);
+		});
+	};
+	var request = function(msg, signal) {
+		var id = msg.id = ++seq;
+		return promise(function(resolve, reject) {
+			if (signal && signal.aborted) {
+				reject(abortError());
+				return;
+			}
+			pending[id] = {resolve: resolve, reject: reject};
+			if (signal) {
+				signal.addEventListener('abort', function() {
+					if (pending[id]) {
+						delete pending[id];
+						window.external.invoke(JSON.stringify({id: id, scope: msg.scope, cancel: true}));
+						reject(abortError());
+					}
+				});
+			}
+			window.external.invoke(JSON.stringify(msg));
+		});
+	};
+	var iterator = function(scope, stream) {
+		var done = false;
+		var it = {
+			next: function() {
+				if (done) {
+					return resolved({value: undefined, done: true});
+				}
+				return request({scope: scope, stream: stream, op: 'next'}).then(function(r) {
+					done = done || r.done;
+					return r;
+				});
+			},
+			'return': function(value) {
+				if (!done) {
+					done = true;
+					request({scope: scope, stream: stream, op: 'close'});
+				}
+				return resolved({value: value, done: true});
+			}
+		};
+		if (typeof Symbol !== 'undefined' && Symbol.asyncIterator) {
+			it[Symbol.asyncIterator] = function() {
+				return it;
+			};
+		}
+		return it;
+	};
+	// iterable returns the result of a method that returns a channel, which
+	// can be iterated with "for await" directly or awaited for the iterator
+	var iterable = function(p) {
+		var r = {
+			then: function(fulfilled, rejected) {
+				return p.then(fulfilled, rejected);
+			},
+			'catch': function(rejected) {
+				return p.then(null, rejected);
+			},
+			next: function() {
+				return p.then(function(it) {
+					return it.next();
+				});
+			},
+			'return': function(value) {
+				return p.then(function(it) {
+					return it['return'](value);
+				});
+			}
+		};
+		if (typeof Symbol !== 'undefined' && Symbol.asyncIterator) {
+			r[Symbol.asyncIterator] = function() {
+				return r;
+			};
+		}
+		return r;
+	};
+	webview._call = function(scope, method, params, stream) {
+		var signal = null;
+		if (typeof AbortSignal !== 'undefined' && params.length > 0 &&
+				params[params.length - 1] instanceof AbortSignal) {
+			signal = params.pop();
+		}
+		for (var i = 0; i < params.length; i++) {
+			if (typeof params[i] === 'function') {
+				callbacks[++callbackSeq] = params[i];
+				params[i] = {$fn: callbackSeq};
+			}
+		}
+		var p = request({scope: scope, method: method, params: params}, signal);
+		return stream ? iterable(p) : p;
+	};
+	webview._callback = function(id, args) {
+		var f = callbacks[id];
+		if (f) {
+			f.apply(null, args);
+		}
+	};
+	webview._release = function(id) {
+		delete callbacks[id];
+	};
+	webview._resolve = function(id, result) {
+		var p = pending[id];
+		if (p) {
+			delete pending[id];
+			if (result && typeof result === 'object' && '$stream' in result) {
+				result = iterator(result.scope, result.$stream);
+			}
+			p.resolve(result);
+		}
+	};
+	webview._reject = function(id, error) {
+		var p = pending[id];
+		if (p) {
+			delete pending[id];
+			var e = new Error(error.message);
+			e.name = error.name;
+			if ('code' in error) {
+				e.code = error.code;
+			}
+			if ('data' in error) {
+				e.data = error.data;
+			}
+			p.reject(e);
+		}
+	};
+	var listeners = {};
+	webview.on = function(event, handler) {
+		(listeners[event] = listeners[event] || []).push(handler);
+	};
+	webview.off = function(event, handler) {
+		if (!handler) {
+			delete listeners[event];
+			return;
+		}
+		var l = listeners[event] || [];
+		for (var i = l.length - 1; i >= 0; i--) {
+			if (l[i] === handler) {
+				l.splice(i, 1);
+			}
+		}
+	};
+	webview._style = function(id, css) {
+		var el = document.getElementById('webview-style-' + id);
+		if (css === null) {
+			if (el) {
+				el.parentNode.removeChild(el);
+			}
+			return;
+		}
+		if (!el) {
+			el = document.createElement('style');
+			el.id = 'webview-style-' + id;
+			el.setAttribute('type', 'text/css');
+			(document.head || document.documentElement).appendChild(el);
+		}
+		if (el.styleSheet) {
+			el.styleSheet.cssText = css;
+		} else {
+			el.textContent = css;
+		}
+	};
+	webview._emit = function(event, payload) {
+		var l = (listeners[event] || []).slice();
+		for (var i = 0; i < l.length; i++) {
+			try {
+				l[i](payload);
+			} catch (e) {
+				if (window.console) {
+					console.error(e);
+				}
+			}
+		}
+	};
+	var ready = function() {
+		window.external.invoke(JSON.stringify({webview: 'ready'}));
+	};
+	if (document.readyState === 'loading') {
+		document.addEventListener('DOMContentLoaded', ready);
+	} else {
+		ready();
+	}
+})();
+`
+
+var bindTmpl = template.Must(template.New("").Parse(`
+{{ if .Func }}
+{{ with index .Methods 0 }}
+window.webview._ns("{{$.Parent}}")["{{$.Base}}"] = function({{.JSArgs}}) {
+	return window.webview._call("{{.Scope}}", "", Array.prototype.slice.call(arguments){{if .Stream}}, true{{end}});
+};
+{{ end }}
+{{ else }}
+window.webview._ns("{{.Name}}");
+{{ range .Methods }}
+window.webview._ns("{{.Scope}}").{{.JSName}} = function({{.JSArgs}}) {
+	return window.webview._call("{{.Scope}}", "{{.Name}}", Array.prototype.slice.call(arguments){{if .Stream}}, true{{end}});
+};
+{{ end }}
+{{ end }}
+`))
+
+// rpcReply is the outcome of a bound method call that is sent back to
+// JavaScript to settle the promise returned by the method stub.
+type rpcReply struct {
+	ID     int
+	Result interface{}
+	Err    error
+	// Page is the page that made the call, see binding.reset()
+	Page int
+	// Sync is the JavaScript code that updates the data of the binding once
+	// the method has returned, see binding.Sync()
+	Sync string
+}
+
+// JS returns the JavaScript code that settles the promise of the call.
+func (r rpcReply) JS() (string, error) {
+	if r.Err == nil {
+		result, err := json.Marshal(r.Result)
+		if err == nil {
+			return fmt.Sprintf("window.webview._resolve(%d,%s);", r.ID, string(result)), nil
+		}
+		r.Err = err
+	}
+	jsErr := newJSError(r.Err)
+	e, err := json.Marshal(jsErr)
+	if err != nil {
+		// Error data can not be encoded, reject without it
+		jsErr.Data = nil
+		if e, err = json.Marshal(jsErr); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("window.webview._reject(%d,%s);", r.ID, string(e)), nil
+}
+
+// Error is an error that can be returned from bound methods to control the
+// properties of the JavaScript Error object the promise is rejected with.
+type Error struct {
+	// Name of the JavaScript error, "Error" if empty
+	Name string
+	// Error message, the message of Err if empty
+	Message string
+	// Optional machine-readable error code
+	Code string
+	// Optional JSON-encodable error details
+	Data interface{}
+	// Optional underlying error
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrorCoder is implemented by errors that carry a machine-readable code. If
+// an error returned from a bound method (or any error it wraps) implements
+// ErrorCoder, the code is exposed as the "code" property of the JavaScript
+// Error object.
+type ErrorCoder interface {
+	Code() string
+}
+
+// ErrorDataProvider is implemented by errors that carry additional details. If
+// an error returned from a bound method (or any error it wraps) implements
+// ErrorDataProvider, the JSON-encoded data is exposed as the "data" property
+// of the JavaScript Error object.
+type ErrorDataProvider interface {
+	Data() interface{}
+}
+
+// jsError is the JSON representation of a Go error passed to JavaScript.
+type jsError struct {
+	Name    string      `json:"name"`
+	Message string      `json:"message"`
+	Code    string      `json:"code,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func newJSError(err error) jsError {
+	e := jsError{Name: "Error", Message: err.Error()}
+	var argErr *ArgumentError
+	if errors.As(err, &argErr) {
+		e.Name = "TypeError"
+	}
+	var we *Error
+	if errors.As(err, &we) {
+		if we.Name != "" {
+			e.Name = we.Name
+		}
+		e.Code = we.Code
+		e.Data = we.Data
+	}
+	var coder ErrorCoder
+	if e.Code == "" && errors.As(err, &coder) {
+		e.Code = coder.Code()
+	}
+	var provider ErrorDataProvider
+	if e.Data == nil && errors.As(err, &provider) {
+		e.Data = provider.Data()
+	}
+	return e
+}
+
+type binding struct {
+	Value   interface{}
+	Name    string
+	Methods []methodInfo
+	// Func is true if the binding is a single function rather than an object,
+	// its only method has an empty name
+	Func bool
+
+	// reply receives the outcome of every call made through Call. It may be
+	// nil if the results are not needed.
+	reply func(r rpcReply)
+	// ctx is the parent context of all calls, context.Background() if nil
+	ctx context.Context
+	// cancel cancels ctx when the binding is removed, if not nil
+	cancel context.CancelFunc
+	// timeout limits the duration of calls that accept a context, if non-zero
+	timeout time.Duration
+	// mode defines where the methods are called
+	mode execMode
+	// exec runs a method call, the call is made synchronously if nil
+	exec func(f func())
+
+	// exclude lists the Go names of the methods that are not exposed
+	exclude []string
+	// nameFunc converts Go method names into JavaScript names, if not nil
+	nameFunc func(name string) string
+	// eval evaluates JavaScript code on the main thread unless another page
+	// has been loaded since the given one, it is used by the JSFunc arguments
+	// and may be called from any goroutine
+	eval func(page int, js string)
+
+	mu sync.Mutex
+	// page is incremented every time a new page is loaded, since the IDs
+	// of the calls restart on every page
+	page      int
+	calls     map[callKey]context.CancelFunc
+	streams   map[int]*stream
+	streamSeq int
+}
+
+// callKey identifies a call across pages
+type callKey struct {
+	page, id int
+}
+
+// BindOption configures a binding created with Bind().
+type BindOption func(b *binding)
+
+type execMode int
+
+const (
+	execMain execMode = iota
+	execSerial
+	execConcurrent
+)
+
+// BindSerial makes the methods run on a worker goroutine instead of the main
+// UI thread, one call at a time in the order they were made. The promise is
+// resolved once the method returns. Use it for slow methods that must not
+// freeze the UI, but are not safe for concurrent use.
+//
+// The value is serialized on the worker goroutine after every call to update
+// the JavaScript object (see Bind()). A panic while it is serialized rejects
+// the call with a *PanicError, as a panic of the method does.
+func BindSerial() BindOption {
+	return func(b *binding) {
+		b.mode = execSerial
+	}
+}
+
+// BindConcurrent makes the methods run on the worker pool instead of the main
+// UI thread. Calls are made concurrently, up to Settings.Workers at a time,
+// so the methods must be safe for concurrent use. The value is serialized on
+// the worker goroutine after every call, so its exported fields must be safe
+// to read while other calls are in progress.
+func BindConcurrent() BindOption {
+	return func(b *binding) {
+		b.mode = execConcurrent
+	}
+}
+
+// BindExclude hides the methods with the given Go names from JavaScript.
+// Methods of nested objects can be hidden by their path relative to the bound
+// value, e.g. "files.Close", or by their name alone, which hides them in all
+// nested objects.
+func BindExclude(names ...string) BindOption {
+	return func(b *binding) {
+		b.exclude = append(b.exclude, names...)
+	}
+}
+
+// BindNameFunc sets the function that converts Go method names into
+// JavaScript names, e.g. to change how acronyms are handled. Names returned by
+// JSNamer take precedence.
+func BindNameFunc(f func(name string) string) BindOption {
+	return func(b *binding) {
+		b.nameFunc = f
+	}
+}
+
+// BindTimeout sets the maximum duration of bound method calls. When the
+// timeout elapses the context passed to the method is cancelled. Methods that
+// don't accept a context.Context are not affected.
+func BindTimeout(d time.Duration) BindOption {
+	return func(b *binding) {
+		b.timeout = d
+	}
+}
+
+// validIdent returns true if id is a valid JavaScript identifier.
+func validIdent(id string) bool {
+	if id == "" {
+		return false
+	}
+	for i, r := range id {
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// validName returns an error unless name is a dot-separated path of
+// JavaScript identifiers, e.g. "app.files".
+func validName(name string) error {
+	for _, id := range strings.Split(name, ".") {
+		if !validIdent(id) {
+			return fmt.Errorf("invalid name %q", name)
+		}
+	}
+	return nil
+}
+
+func newBinding(name string, v interface{}, opts ...BindOption) (*binding, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+	methods, err := getMethods(name, v)
+	if err != nil {
+		return nil, err
+	}
+	b := &binding{Name: name, Value: v}
+	for _, opt := range opts {
+		opt(b)
+	}
+	for _, mi := range methods {
+		if b.excluded(mi) {
+			continue
+		}
+		if mi.jsName == "" && b.nameFunc != nil {
+			mi.jsName = b.nameFunc(mi.Name)
+			if !validIdent(mi.jsName) {
+				return nil, fmt.Errorf("%s.%s: invalid JavaScript name %q", mi.Scope, mi.Name, mi.jsName)
+			}
+		}
+		b.Methods = append(b.Methods, mi)
+	}
+	return b, nil
+}
+
+// excluded returns true if the method is hidden with BindExclude().
+func (b *binding) excluded(mi methodInfo) bool {
+	path := strings.TrimPrefix(strings.TrimPrefix(mi.Scope, b.Name), ".")
+	for _, name := range b.exclude {
+		if name == mi.Name || (path != "" && name == path+"."+mi.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func newFuncBinding(name string, fn interface{}, opts ...BindOption) (*binding, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, errors.New("must be a function")
+	}
+	if v.IsNil() {
+		return nil, errors.New("function can not be nil")
+	}
+	b := &binding{Name: name, Methods: []methodInfo{{Scope: name, Value: v}}, Func: true}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, nil
+}
+
+// Parent returns the name of the namespace the binding belongs to, e.g. "app"
+// for "app.files", or an empty string for global bindings.
+func (b *binding) Parent() string {
+	if i := strings.LastIndex(b.Name, "."); i >= 0 {
+		return b.Name[:i]
+	}
+	return ""
+}
+
+// Base returns the last element of the binding name, e.g. "files" for
+// "app.files".
+func (b *binding) Base() string {
+	return b.Name[strings.LastIndex(b.Name, ".")+1:]
+}
+
+func (b *binding) JS() (string, error) {
+	js := &bytes.Buffer{}
+	err := bindTmpl.Execute(js, b)
+	return js.String(), err
+}
+
+func (b *binding) Sync() (string, error) {
+	if b.Func {
+		return "", nil
+	}
+	js, err := json.Marshal(b.Value)
+	if err == nil {
+		return fmt.Sprintf("%[1]s.data=%[2]s;if(%[1]s.render){%[1]s.render(%[2]s);}", b.Name, string(js)), nil
+	}
+	return "", err
+}
+
+func (b *binding) Call(js string) bool {
+	type rpcCall struct {
+		ID     int               `json:"id"`
+		Scope  string            `json:"scope"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+		Cancel bool              `json:"cancel"`
+		Stream int               `json:"stream"`
+		Op     string            `json:"op"`
+	}
+
+	rpc := rpcCall{}
+	if err := json.Unmarshal([]byte(js), &rpc); err != nil {
+		return false
+	}
+	if rpc.Scope != b.Name && !strings.HasPrefix(rpc.Scope, b.Name+".") {
+		return false
+	}
+	b.mu.Lock()
+	key := callKey{page: b.page, id: rpc.ID}
+	b.mu.Unlock()
+	if rpc.Cancel {
+		b.mu.Lock()
+		if cancel, ok := b.calls[key]; ok {
+			cancel()
+		}
+		b.mu.Unlock()
+		return true
+	}
+	if rpc.Stream != 0 {
+		b.streamCall(key, rpc.Stream, rpc.Op)
+		return true
+	}
+	var mi *methodInfo
+	for i := 0; i < len(b.Methods); i++ {
+		if b.Methods[i].Scope == rpc.Scope && b.Methods[i].Name == rpc.Method {
+			mi = &b.Methods[i]
+			break
+		}
+	}
+	if mi == nil {
+		return false
+	}
+	args, err := mi.Args(b.qualify(mi), rpc.Params)
+	if err != nil {
+		if b.reply != nil {
+			b.reply(rpcReply{ID: rpc.ID, Err: err, Page: key.page})
+		}
+		return true
+	}
+	for _, arg := range args {
+		switch arg.Type() {
+		case jsFuncType:
+			arg.Addr().Interface().(*JSFunc).bind(b, key.page)
+		case reflect.PtrTo(jsFuncType):
+			if !arg.IsNil() {
+				arg.Interface().(*JSFunc).bind(b, key.page)
+			}
+		}
+	}
+	ctx := b.context(key, mi)
+	call := func() {
+		// The method itself recovers its panics, but encoding the bound value
+		// may panic too, e.g. in MarshalJSON(), and this may run on a worker
+		// goroutine where nothing else would recover
+		defer func() {
+			if r := recover(); r != nil {
+				b.done(key)
+				if b.reply != nil {
+					b.reply(rpcReply{ID: rpc.ID, Err: newPanicError(b.qualify(mi), r), Page: key.page})
+				}
+			}
+		}()
+		result, err := mi.Call(ctx, b.qualify(mi), args)
+		if v := reflect.ValueOf(result); err == nil && v.Kind() == reflect.Chan && v.Type().ChanDir()&reflect.RecvDir != 0 {
+			result = b.stream(key, ctx, mi.Scope, v)
+		} else {
+			b.done(key)
+		}
+		if b.reply != nil {
+			r := rpcReply{ID: rpc.ID, Result: result, Err: err, Page: key.page}
+			// The value is serialized where the method was called, before
+			// the next call of the worker pool can change it
+			if r.Sync, err = b.Sync(); err != nil {
+				log.Println(err)
+			}
+			b.reply(r)
+		}
+	}
+	if b.exec != nil {
+		b.exec(call)
+	} else {
+		call()
+	}
+	return true
+}
+
+// workerPool runs functions on at most size goroutines at a time. Functions
+// are started in the order they were submitted. If parent is not nil, the
+// goroutines are taken from the parent pool.
+type workerPool struct {
+	size   int
+	parent *workerPool
+
+	mu      sync.Mutex
+	queue   []func()
+	running int
+}
+
+// Submit schedules f to run on the pool. It never blocks.
+func (p *workerPool) Submit(f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = append(p.queue, f)
+	if p.running >= p.size {
+		return
+	}
+	p.running++
+	if p.parent != nil {
+		p.parent.Submit(p.work)
+	} else {
+		go p.work()
+	}
+}
+
+func (p *workerPool) work() {
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		f := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+		f()
+	}
+}
+
+// stream is a channel returned by a bound method, which is read from
+// JavaScript using an async iterator.
+type stream struct {
+	ch     reflect.Value
+	ctx    context.Context
+	cancel context.CancelFunc
+	// recv receives the values one at a time, in the order they were
+	// requested
+	recv *workerPool
+}
+
+type streamRef struct {
+	ID    int    `json:"$stream"`
+	Scope string `json:"scope"`
+}
+
+type streamValue struct {
+	Value interface{} `json:"value"`
+	Done  bool        `json:"done"`
+}
+
+type streamDone struct {
+	Done bool `json:"done"`
+}
+
+// stream registers the channel returned by the call with the given ID. The
+// context of the call, if any, is cancelled when the stream is closed rather
+// than when the call returns.
+func (b *binding) stream(key callKey, ctx context.Context, scope string, ch reflect.Value) streamRef {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := &stream{ch: ch, recv: &workerPool{size: 1}}
+	if cancel, ok := b.calls[key]; ok {
+		delete(b.calls, key)
+		s.ctx, s.cancel = ctx, cancel
+	} else {
+		s.ctx = b.ctx
+		if s.ctx == nil {
+			s.ctx = context.Background()
+		}
+		s.ctx, s.cancel = context.WithCancel(s.ctx)
+	}
+	if b.streams == nil {
+		b.streams = map[int]*stream{}
+	}
+	b.streamSeq++
+	b.streams[b.streamSeq] = s
+	return streamRef{ID: b.streamSeq, Scope: scope}
+}
+
+// streamCall handles the requests of the async iterator: "next" receives the
+// next value from the channel and "close" closes the stream.
+func (b *binding) streamCall(key callKey, sid int, op string) {
+	b.mu.Lock()
+	s := b.streams[sid]
+	b.mu.Unlock()
+	reply := func(result interface{}) {
+		if b.reply != nil {
+			b.reply(rpcReply{ID: key.id, Result: result, Page: key.page})
+		}
+	}
+	if s == nil {
+		reply(streamDone{Done: true})
+		return
+	}
+	switch op {
+	case "next":
+		s.recv.Submit(func() {
+			if s.ch.IsNil() {
+				b.closeStream(sid)
+				reply(streamDone{Done: true})
+				return
+			}
+			chosen, v, ok := reflect.Select([]reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: s.ch},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.ctx.Done())},
+			})
+			if chosen == 0 && ok {
+				reply(streamValue{Value: v.Interface()})
+			} else {
+				b.closeStream(sid)
+				reply(streamDone{Done: true})
+			}
+		})
+	case "close":
+		b.closeStream(sid)
+		reply(nil)
+	}
+}
+
+// closeStream closes the stream with the given ID, if any.
+func (b *binding) closeStream(sid int) {
+	b.mu.Lock()
+	s := b.streams[sid]
+	delete(b.streams, sid)
+	b.mu.Unlock()
+	if s != nil {
+		s.close()
+	}
+}
+
+// reset is called when a new page is loaded. It cancels the calls and closes
+// the streams of the previous page, whose replies are dropped, see current().
+func (b *binding) reset() {
+	b.mu.Lock()
+	b.page++
+	calls, streams := b.calls, b.streams
+	b.calls, b.streams = nil, nil
+	b.mu.Unlock()
+	for _, cancel := range calls {
+		cancel()
+	}
+	for _, s := range streams {
+		s.close()
+	}
+}
+
+// close cancels the calls and closes the streams of a binding that has been
+// replaced or removed.
+func (b *binding) close() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.mu.Lock()
+	streams := b.streams
+	b.streams = nil
+	b.mu.Unlock()
+	for _, s := range streams {
+		s.close()
+	}
+}
+
+// current returns false if the reply of a call made by the given page must be
+// dropped, because another page has been loaded since.
+func (b *binding) current(page int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return page == b.page
+}
+
+// close cancels the context of the stream and drains the channel in the
+// background, so that the sender is not blocked forever.
+func (s *stream) close() {
+	s.cancel()
+	if !s.ch.IsNil() {
+		go func() {
+			for {
+				if _, ok := s.ch.Recv(); !ok {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// qualify returns the qualified name of the method used in error messages,
+// e.g. "app.files.Open", or just the name of the binding for function
+// bindings.
+func (b *binding) qualify(mi *methodInfo) string {
+	if b.Func {
+		return mi.Scope
+	}
+	return mi.Scope + "." + mi.Name
+}
+
+// context returns a new context for the given call. The context is cancelled
+// when JavaScript aborts the call, when the timeout elapses, when done() is
+// called or when another page is loaded.
+func (b *binding) context(key callKey, mi *methodInfo) context.Context {
+	if !mi.Context() {
+		return nil
+	}
+	ctx := b.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var cancel context.CancelFunc
+	if b.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	b.mu.Lock()
+	if b.calls == nil {
+		b.calls = map[callKey]context.CancelFunc{}
+	}
+	b.calls[key] = cancel
+	b.mu.Unlock()
+	return ctx
+}
+
+// done releases the context of the given call, if any.
+func (b *binding) done(key callKey) {
+	b.mu.Lock()
+	if cancel, ok := b.calls[key]; ok {
+		cancel()
+		delete(b.calls, key)
+	}
+	b.mu.Unlock()
+}
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	jsFuncType  = reflect.TypeOf(JSFunc{})
+)
+
+// JSFunc is a handle to a JavaScript function passed as an argument to a bound
+// method. Declare a method argument of type JSFunc (or *JSFunc to make it
+// optional) to accept a callback from JavaScript, e.g.
+//
+//	func (w *Watcher) Watch(path string, fn webview.JSFunc)
+//
+// can be called as "watcher.watch('/tmp', function(event) {...})". JSFunc
+// can only be used for arguments of the method itself, not for values nested
+// in other arguments. The function is kept alive in JavaScript until Release()
+// is called or the page is unloaded, it can't be called after that.
+type JSFunc struct {
+	id int
+	// page is the page of the binding that created the function
+	page int
+	b    *binding
+}
+
+func (f *JSFunc) bind(b *binding, page int) {
+	f.b, f.page = b, page
+}
+
+// UnmarshalJSON decodes the reference to the JavaScript function.
+func (f *JSFunc) UnmarshalJSON(b []byte) error {
+	var ref struct {
+		ID *int `json:"$fn"`
+	}
+	if err := json.Unmarshal(b, &ref); err != nil {
+		return err
+	}
+	if ref.ID == nil {
+		return errors.New("not a function")
+	}
+	f.id = *ref.ID
+	return nil
+}
+
+// Call calls the JavaScript function with the JSON-encoded arguments. The
+// function is called asynchronously on the main thread, Call may be used from
+// any goroutine.
+func (f JSFunc) Call(args ...interface{}) error {
+	if f.b == nil || f.b.eval == nil {
+		return errors.New("webview: JSFunc is not bound to a webview")
+	}
+	if !f.b.current(f.page) {
+		return errors.New("webview: JSFunc belongs to a page that is unloaded")
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	js, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	f.b.eval(f.page, fmt.Sprintf("window.webview._callback(%d,%s);", f.id, string(js)))
+	return nil
+}
+
+// Release releases the JavaScript function. It can't be called after that.
+// It does nothing if the page that created the function is unloaded.
+func (f JSFunc) Release() {
+	if f.b != nil && f.b.eval != nil {
+		f.b.eval(f.page, fmt.Sprintf("window.webview._release(%d);", f.id))
+	}
+}
+
+// JSError is a JavaScript exception thrown by the code evaluated with
+// EvalResult() or by the function called with CallJS().
+type JSError struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Stack   string `json:"stack"`
+}
+
+func (e *JSError) Error() string {
+	return e.Name + ": " + e.Message
+}
+
+// PanicError is a panic recovered from a bound method or from a function
+// scheduled with Dispatch().
+type PanicError struct {
+	// Where is the name of the bound method (e.g. "counter.Add") or
+	// "Dispatch", "EvalAsync", "OnReady", "OnLoad", "NavigationPolicy",
+	// "Schemes" or "ExternalInvokeCallback"
+	Where string
+	// Value is the value passed to panic()
+	Value interface{}
+	// Stack is the stack trace of the goroutine at the time of the panic
+	Stack []byte
+}
+
+func newPanicError(where string, v interface{}) *PanicError {
+	return &PanicError{Where: where, Value: v, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("webview: panic in %s: %v", e.Where, e.Value)
+}
+
+// ArgumentError describes a failure to convert the JSON parameters passed
+// from JavaScript into the arguments of a bound method.
+type ArgumentError struct {
+	// Method is the qualified name of the method, e.g. "counter.Add"
+	Method string
+	// Index is the index of the offending argument, or -1 if the number of
+	// parameters does not match the number of arguments
+	Index int
+	// Type is the Go type of the argument, nil if Index is -1
+	Type reflect.Type
+	// Path is the JSON path of the value that can not be decoded, e.g.
+	// "params[1].Points"
+	Path string
+	// Err is the underlying error, usually returned by the json package
+	Err error
+}
+
+func (e *ArgumentError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("webview: %s: %v", e.Method, e.Err)
+	}
+	return fmt.Sprintf("webview: %s: argument %d (%s) can not be decoded into %v: %v",
+		e.Method, e.Index, e.Path, e.Type, e.Err)
+}
+
+func (e *ArgumentError) Unwrap() error { return e.Err }
+
+// Data returns the details of the error, exposed as the "data" property of the
+// JavaScript error object.
+func (e *ArgumentError) Data() interface{} {
+	data := map[string]interface{}{"method": e.Method, "index": e.Index}
+	if e.Type != nil {
+		data["type"] = e.Type.String()
+		data["path"] = e.Path
+	}
+	return data
+}
+
+type methodInfo struct {
+	// Scope is the qualified name of the JavaScript object the method belongs
+	// to, e.g. "app.files"
+	Scope string
+	Name  string
+	Value reflect.Value
+
+	jsName string
+}
+
+// MethodLister can be implemented by bound values to limit the methods
+// exposed to JavaScript. Only the methods with the Go names returned by
+// WebViewMethods() are exposed, the WebViewMethods() method itself never is.
+type MethodLister interface {
+	WebViewMethods() []string
+}
+
+// JSNamer can be implemented by bound values to choose the JavaScript names of
+// their methods. JSName() is called with the Go name of every exposed method
+// and returns the JavaScript name, an empty string to use the default name,
+// or "-" to hide the method. The JSName() method itself is never exposed.
+type JSNamer interface {
+	JSName(method string) string
+}
+
+// Context returns true if the first argument of the method is a
+// context.Context.
+func (mi methodInfo) Context() bool {
+	t := mi.Value.Type()
+	return t.NumIn() > 0 && t.In(0) == contextType
+}
+
+// Arity returns the number of arguments passed from JavaScript, not counting
+// the context.
+func (mi methodInfo) Arity() int {
+	n := mi.Value.Type().NumIn()
+	if mi.Context() {
+		n--
+	}
+	if mi.Variadic() {
+		n--
+	}
+	return n
+}
+
+// Variadic returns true if the method accepts a variable number of trailing
+// arguments, which are not counted by Arity.
+func (mi methodInfo) Variadic() bool {
+	return mi.Value.Type().IsVariadic()
+}
+
+// Stream returns true if the method returns a receive channel, besides an
+// error, so that its result can be iterated in JavaScript.
+func (mi methodInfo) Stream() bool {
+	t := mi.Value.Type()
+	n := t.NumOut()
+	if n > 0 && t.Out(n-1) == errorType {
+		n--
+	}
+	return n == 1 && t.Out(0).Kind() == reflect.Chan && t.Out(0).ChanDir()&reflect.RecvDir != 0
+}
+
+// MinArity returns the number of arguments that must be passed from
+// JavaScript. Trailing pointer arguments are optional and nil if omitted.
+func (mi methodInfo) MinArity() int {
+	offset := mi.Value.Type().NumIn() - mi.Arity()
+	if mi.Variadic() {
+		offset--
+	}
+	n := mi.Arity()
+	for n > 0 && mi.Value.Type().In(offset+n-1).Kind() == reflect.Ptr {
+		n--
+	}
+	return n
+}
+
+// Args decodes JSON parameters into the method arguments, not including the
+// context. Omitted optional arguments are set to nil, and the remaining
+// parameters are passed as the variadic arguments, if any. The name of the
+// method is only used for error reporting.
+func (mi methodInfo) Args(name string, params []json.RawMessage) ([]reflect.Value, error) {
+	if n := len(params); n < mi.MinArity() || (n > mi.Arity() && !mi.Variadic()) {
+		want := fmt.Sprint(mi.Arity())
+		if mi.Variadic() {
+			want = fmt.Sprintf("at least %d", mi.MinArity())
+		} else if mi.MinArity() < mi.Arity() {
+			want = fmt.Sprintf("%d to %d", mi.MinArity(), mi.Arity())
+		}
+		return nil, &ArgumentError{
+			Method: name,
+			Index:  -1,
+			Err:    fmt.Errorf("expected %s arguments, got %d", want, n),
+		}
+	}
+	t := mi.Value.Type()
+	offset := t.NumIn() - mi.Arity()
+	if mi.Variadic() {
+		offset--
+	}
+	args := make([]reflect.Value, len(params))
+	for i := range args {
+		var arg reflect.Type
+		if i < mi.Arity() {
+			arg = t.In(offset + i)
+		} else {
+			arg = t.In(t.NumIn() - 1).Elem()
+		}
+		u := reflect.New(arg)
+		if err := json.Unmarshal(params[i], u.Interface()); err != nil {
+			path := fmt.Sprintf("params[%d]", i)
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field != "" {
+				// Field is a dot-separated path, use brackets for indices
+				for _, f := range strings.Split(typeErr.Field, ".") {
+					if _, err := strconv.Atoi(f); err == nil {
+						path = path + "[" + f + "]"
+					} else {
+						path = path + "." + f
+					}
+				}
+			}
+			return nil, &ArgumentError{Method: name, Index: i, Type: arg, Path: path, Err: err}
+		}
+		args[i] = u.Elem()
+	}
+	// Omitted optional arguments are nil
+	for i := len(args); i < mi.Arity(); i++ {
+		args = append(args, reflect.Zero(t.In(offset+i)))
+	}
+	return args, nil
+}
+
+// Call calls the method and converts the returned values using Result. The
+// context is passed as the first argument if the method accepts one. If the
+// method panics, the panic is recovered and returned as a *PanicError.
+func (mi methodInfo) Call(ctx context.Context, name string, args []reflect.Value) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, newPanicError(name, r)
+		}
+	}()
+	if mi.Context() {
+		args = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, args...)
+	}
+	return mi.Result(mi.Value.Call(args))
+}
+
+// Result converts the values returned by the method into a single value that
+// can be encoded as JSON: nil if the method returns nothing, the value itself
+// if it returns exactly one value, or a slice of all values otherwise. If the
+// last value returned by the method is an error, it is returned separately
+// and is not a part of the result.
+func (mi methodInfo) Result(results []reflect.Value) (interface{}, error) {
+	if n := len(results); n > 0 && mi.Value.Type().Out(n-1) == errorType {
+		if err, _ := results[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		results = results[:n-1]
+	}
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0].Interface(), nil
+	}
+	values := make([]interface{}, len(results))
+	for i, r := range results {
+		values[i] = r.Interface()
+	}
+	return values, nil
+}
+
+// JSName returns the name of the JavaScript method. Unless set explicitly,
+// it is the camel-case Go name starting with a lower-case letter, with
+// leading acronyms lower-cased as well, e.g. "FooBar" becomes "fooBar" and
+// "HTTPGet" becomes "httpGet".
+func (mi methodInfo) JSName() string {
+	if mi.jsName != "" {
+		return mi.jsName
+	}
+	r := []rune(mi.Name)
+	for i := range r {
+		if !unicode.IsUpper(r[i]) {
+			break
+		}
+		// Keep the last upper-case letter of an acronym followed by a word
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// JSArgs returns the names of the required arguments of the JavaScript
+// method. Optional and variadic arguments are accessed through "arguments".
+func (mi methodInfo) JSArgs() (js string) {
+	for i := 0; i < mi.MinArity(); i++ {
+		if i > 0 {
+			js = js + ","
+		}
+		js = js + fmt.Sprintf("a%d", i)
+	}
+	return js
+}
+
+func getMethods(scope string, obj interface{}) ([]methodInfo, error) {
+	p := reflect.ValueOf(obj)
+	v := reflect.Indirect(p)
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return nil, errors.New("object can not be nil")
+	}
+	k := t.Kind()
+	if k == reflect.Ptr {
+		k = v.Type().Kind()
+	}
+	if k != reflect.Struct {
+		return nil, errors.New("must be a struct or a pointer to a struct")
+	}
+
+	return collectMethods(scope, p, map[visit]bool{})
+}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// collectMethods returns the exported methods of p, as well as the methods of
+// all struct fields tagged with `webview:"name"`, which are exposed as nested
+// JavaScript objects with the given name.
+func collectMethods(scope string, p reflect.Value, seen map[visit]bool) ([]methodInfo, error) {
+	if p.Kind() == reflect.Ptr {
+		if p.IsNil() {
+			return nil, nil
+		}
+		v := visit{p.Pointer(), p.Type()}
+		if seen[v] {
+			return nil, nil
+		}
+		seen[v] = true
+	}
+
+	var (
+		allowed map[string]bool
+		hidden  = map[string]bool{}
+	)
+	lister, _ := p.Interface().(MethodLister)
+	if lister != nil {
+		hidden["WebViewMethods"] = true
+		allowed = map[string]bool{}
+		for _, name := range lister.WebViewMethods() {
+			allowed[name] = true
+		}
+	}
+	namer, _ := p.Interface().(JSNamer)
+	if namer != nil {
+		hidden["JSName"] = true
+	}
+
+	methods := []methodInfo{}
+	t := p.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if !unicode.IsUpper([]rune(method.Name)[0]) || hidden[method.Name] {
+			continue
+		}
+		if allowed != nil && !allowed[method.Name] {
+			continue
 		}
 		mi := methodInfo{
+			Scope: scope,
 			Name:  method.Name,
 			Value: p.MethodByName(method.Name),
 		}
+		if namer != nil {
+			mi.jsName = namer.JSName(method.Name)
+			if mi.jsName == "-" {
+				continue
+			}
+			if mi.jsName != "" && !validIdent(mi.jsName) {
+				return nil, fmt.Errorf("%s.%s: invalid JavaScript name %q", scope, method.Name, mi.jsName)
+			}
+		}
 		methods = append(methods, mi)
 	}
 
+	v := reflect.Indirect(p)
+	if v.Kind() != reflect.Struct {
+		return methods, nil
+	}
+	// Fields promoted from embedded structs are visible as well
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		name := f.Tag.Get("webview")
+		if f.PkgPath != "" || !validIdent(name) {
+			continue
+		}
+		fv, err := v.FieldByIndexErr(f.Index)
+		if err != nil {
+			// Promoted through a nil embedded pointer
+			continue
+		}
+		if fv.Kind() == reflect.Interface {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && fv.CanAddr() {
+			fv = fv.Addr()
+		}
+		if reflect.Indirect(fv).Kind() != reflect.Struct {
+			continue
+		}
+		nested, err := collectMethods(scope+"."+name, fv, seen)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, nested...)
+	}
 	return methods, nil
 }
 
-func (w *webview) Bind(name string, v interface{}) (sync func(), err error) {
-	b, err := newBinding(name, v)
+func (w *webview) Bind(name string, v interface{}, opts ...BindOption) (sync func(), err error) {
+	b, err := newBinding(name, v, opts...)
 	if err != nil {
 		return nil, err
 	}
+	return w.bind(b)
+}
+
+func (w *webview) BindFunc(name string, fn interface{}, opts ...BindOption) error {
+	b, err := newFuncBinding(name, fn, opts...)
+	if err != nil {
+		return err
+	}
+	_, err = w.bind(b)
+	return err
+}
+
+func (w *webview) bind(b *binding) (sync func(), err error) {
+	b.ctx, b.cancel = context.WithCancel(w.ctx)
+	b.eval = func(page int, js string) {
+		// Pages are loaded on the main thread, the page can't change before
+		// the code is evaluated
+		w.Dispatch(func() {
+			if b.current(page) {
+				w.Eval(js)
+			}
+		})
+	}
+	switch b.mode {
+	case execSerial:
+		b.exec = (&workerPool{size: 1, parent: w.pool}).Submit
+	case execConcurrent:
+		b.exec = w.pool.Submit
+	}
 	js, err := b.JS()
 	if err != nil {
+		b.cancel()
 		return nil, err
 	}
 	sync = func() {
 		if js, err := b.Sync(); err != nil {
 			log.Println(err)
-		} else {
+		} else if js != "" {
 			w.Eval(js)
 		}
 	}
 
-	m.Lock()
-	cb := cbs[w]
-	cbs[w] = func(w WebView, data string) {
-		if ok := b.Call(data); ok {
-			sync()
+	reply := func(r rpcReply) {
+		// Sync before settling the promise, so that the promise callbacks
+		// observe the updated data.
+		if r.Sync != "" {
+			w.Eval(r.Sync)
+		}
+		var (
+			argErr   *ArgumentError
+			panicErr *PanicError
+		)
+		if errors.As(r.Err, &argErr) {
+			w.handleCallError(r.Err)
+		} else if errors.As(r.Err, &panicErr) {
+			w.handlePanic(panicErr)
+		}
+		if r.ID == 0 || !b.current(r.Page) {
+			return
+		}
+		if js, err := r.JS(); err != nil {
+			log.Println(err)
 		} else {
-			cb(w, data)
+			w.Eval(js)
 		}
 	}
-	m.Unlock()
+	b.reply = func(r rpcReply) {
+		w.Dispatch(func() { reply(r) })
+	}
 
-	w.Eval(js)
+	if old := w.bindings.Add(b); old != nil {
+		old.close()
+		js = fmt.Sprintf("window.webview._unbind(%q);", b.Name) + js
+	}
+	// The init scripts install the binding in the pages loaded later, Eval
+	// installs it in the current page
+	w.updateInitScripts()
+	w.Eval(runtimeJS + js)
 	sync()
 	return sync, nil
 }
+
+func (w *webview) Unbind(name string) error {
+	b := w.bindings.Remove(name)
+	if b == nil {
+		return fmt.Errorf("%q is not bound", name)
+	}
+	b.close()
+	w.updateInitScripts()
+	return w.Eval(fmt.Sprintf("window.webview._unbind(%q);", name))
+}
+
+func (w *webview) Bindings() []BindingInfo {
+	return w.bindings.Info()
+}
+
+// BindingInfo describes a value or a function registered with Bind() or
+// BindFunc().
+type BindingInfo struct {
+	// Name of the JavaScript object or function
+	Name string
+	// Func is true for functions registered with BindFunc()
+	Func bool
+	// Methods exposed to JavaScript, a single method with an empty name for
+	// functions
+	Methods []MethodInfo
+}
+
+// MethodInfo describes a bound method.
+type MethodInfo struct {
+	// Scope is the name of the JavaScript object the method belongs to, e.g.
+	// "app.files"
+	Scope string
+	// Name of the Go method
+	Name string
+	// JSName is the name of the JavaScript method
+	JSName string
+	// Arity is the number of arguments accepted from JavaScript, not counting
+	// the variadic arguments
+	Arity int
+	// MinArity is the number of required arguments, trailing pointer
+	// arguments are optional
+	MinArity int
+	// Variadic is true if the method accepts a variable number of arguments
+	Variadic bool
+}
+
+// registry keeps track of the bindings of a webview and routes calls from
+// JavaScript to them.
+type registry struct {
+	mu       sync.Mutex
+	bindings map[string]*binding
+}
+
+// Add registers the binding, replacing and returning the binding with the
+// same name, if any.
+func (r *registry) Add(b *binding) *binding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.bindings == nil {
+		r.bindings = map[string]*binding{}
+	}
+	old := r.bindings[b.Name]
+	r.bindings[b.Name] = b
+	return old
+}
+
+// Remove removes and returns the binding with the given name, if any.
+func (r *registry) Remove(name string) *binding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.bindings[name]
+	delete(r.bindings, name)
+	return b
+}
+
+// Call passes the message from JavaScript to the binding with the longest
+// name matching the scope of the message. It returns false if no binding
+// handles the message.
+func (r *registry) Call(data string) bool {
+	var rpc struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.Unmarshal([]byte(data), &rpc); err != nil || rpc.Scope == "" {
+		return false
+	}
+	r.mu.Lock()
+	var b *binding
+	for name := rpc.Scope; b == nil; {
+		b = r.bindings[name]
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	r.mu.Unlock()
+	return b != nil && b.Call(data)
+}
+
+// Reset cancels the calls of the previous page in all bindings, see
+// binding.reset().
+func (r *registry) Reset() {
+	r.mu.Lock()
+	bindings := make([]*binding, 0, len(r.bindings))
+	for _, b := range r.bindings {
+		bindings = append(bindings, b)
+	}
+	r.mu.Unlock()
+	for _, b := range bindings {
+		b.reset()
+	}
+}
+
+// JS returns the JavaScript code that creates all registered bindings, sorted
+// by name so that parents are created before children.
+func (r *registry) JS() string {
+	return r.js(func(b *binding) (string, error) { return b.JS() })
+}
+
+// Sync returns the JavaScript code that updates the data of all registered
+// bindings.
+func (r *registry) Sync() string {
+	return r.js(func(b *binding) (string, error) { return b.Sync() })
+}
+
+func (r *registry) js(f func(b *binding) (string, error)) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.bindings))
+	for name := range r.bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var js strings.Builder
+	for _, name := range names {
+		if s, err := f(r.bindings[name]); err != nil {
+			log.Println(err)
+		} else {
+			js.WriteString(s)
+		}
+	}
+	return js.String()
+}
+
+// Info describes all registered bindings, sorted by name.
+func (r *registry) Info() []BindingInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info := []BindingInfo{}
+	for _, b := range r.bindings {
+		bi := BindingInfo{Name: b.Name, Func: b.Func}
+		for _, mi := range b.Methods {
+			bi.Methods = append(bi.Methods, MethodInfo{
+				Scope:    mi.Scope,
+				Name:     mi.Name,
+				JSName:   mi.JSName(),
+				Arity:    mi.Arity(),
+				MinArity: mi.MinArity(),
+				Variadic: mi.Variadic(),
+			})
+		}
+		info = append(info, bi)
+	}
+	sort.Slice(info, func(i, j int) bool { return info[i].Name < info[j].Name })
+	return info
+}
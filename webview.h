@@ -40,7 +40,11 @@ extern "C" {
 
 #if defined(WEBVIEW_GTK)
 #include <JavaScriptCore/JavaScript.h>
+#include <errno.h>
+#include <gio/gunixinputstream.h>
 #include <gtk/gtk.h>
+#include <sys/socket.h>
+#include <unistd.h>
 #include <webkit2/webkit2.h>
 
 struct webview_priv {
@@ -49,8 +53,10 @@ struct webview_priv {
   GtkWidget *webview;
   GtkWidget *inspector_window;
   GAsyncQueue *queue;
+  GQueue *evals;
+  WebKitWebContext *context;
+  GList *scheme_tasks;
   int ready;
-  int js_busy;
   int should_exit;
 };
 #elif defined(WEBVIEW_WINAPI)
@@ -72,6 +78,10 @@ struct webview_priv {
   DWORD saved_style;
   DWORD saved_ex_style;
   RECT saved_rect;
+  READYSTATE ready_state;
+  int ready;
+  char **init_scripts;
+  int init_scripts_len;
 };
 #elif defined(WEBVIEW_COCOA)
 #include <objc/objc-runtime.h>
@@ -93,6 +103,50 @@ struct webview;
 
 typedef void (*webview_external_invoke_cb_t)(struct webview *w,
                                              const char *arg);
+typedef void (*webview_ready_cb_t)(struct webview *w);
+
+enum webview_navigation_type {
+  WEBVIEW_NAVIGATION_TYPE_OTHER = 0,
+  WEBVIEW_NAVIGATION_TYPE_LINK = 1,
+  WEBVIEW_NAVIGATION_TYPE_FORM_SUBMIT = 2,
+  WEBVIEW_NAVIGATION_TYPE_BACK_FORWARD = 3,
+  WEBVIEW_NAVIGATION_TYPE_RELOAD = 4,
+  WEBVIEW_NAVIGATION_TYPE_FORM_RESUBMIT = 5
+};
+
+enum webview_navigation_decision {
+  WEBVIEW_NAVIGATION_ALLOW = 0,
+  WEBVIEW_NAVIGATION_BLOCK = 1,
+  WEBVIEW_NAVIGATION_OPEN_EXTERNAL = 2
+};
+
+typedef enum webview_navigation_decision (*webview_navigation_cb_t)(
+    struct webview *w, const char *url, enum webview_navigation_type type,
+    int new_window);
+
+enum webview_load_event {
+  WEBVIEW_LOAD_STARTED = 0,
+  WEBVIEW_LOAD_REDIRECTED = 1,
+  WEBVIEW_LOAD_COMMITTED = 2,
+  WEBVIEW_LOAD_FINISHED = 3,
+  WEBVIEW_LOAD_FAILED = 4,
+  WEBVIEW_LOAD_PROGRESS = 5
+};
+
+/* err is only set for WEBVIEW_LOAD_FAILED, progress is the estimated load
+   progress between 0 and 1 */
+typedef void (*webview_load_cb_t)(struct webview *w,
+                                  enum webview_load_event event,
+                                  const char *url, const char *err,
+                                  double progress);
+
+/* A request to one of the custom URI schemes of the webview */
+struct webview_scheme_task;
+
+/* Called on the main thread for every request to a custom URI scheme, the
+   task must be finished with webview_scheme_task_finish() */
+typedef void (*webview_scheme_cb_t)(struct webview *w,
+                                    struct webview_scheme_task *task);
 
 struct webview {
   const char *url;
@@ -102,6 +156,16 @@ struct webview {
   int resizable;
   int debug;
   webview_external_invoke_cb_t external_invoke_cb;
+  /* Called on the main thread every time a page has finished loading */
+  webview_ready_cb_t ready_cb;
+  /* Decides whether a page is loaded, not supported on Windows */
+  webview_navigation_cb_t navigation_cb;
+  /* Called on the main thread on every step of a page load */
+  webview_load_cb_t load_cb;
+  /* NULL-terminated list of the custom URI schemes handled by scheme_cb,
+     not supported on Windows */
+  const char **schemes;
+  webview_scheme_cb_t scheme_cb;
   struct webview_priv priv;
   void *userdata;
 };
@@ -122,6 +186,9 @@ enum webview_dialog_type {
 
 typedef void (*webview_dispatch_fn)(struct webview *w, void *arg);
 
+typedef void (*webview_eval_result_fn)(struct webview *w, void *arg,
+                                       const char *result, const char *err);
+
 struct webview_dispatch_arg {
   webview_dispatch_fn fn;
   struct webview *w;
@@ -156,7 +223,14 @@ WEBVIEW_API int webview(const char *title, const char *url, int width,
 WEBVIEW_API int webview_init(struct webview *w);
 WEBVIEW_API int webview_loop(struct webview *w, int blocking);
 WEBVIEW_API int webview_eval(struct webview *w, const char *js);
+WEBVIEW_API int webview_eval_result(struct webview *w, const char *js,
+                                    webview_eval_result_fn fn, void *arg);
 WEBVIEW_API int webview_inject_css(struct webview *w, const char *css);
+WEBVIEW_API int webview_add_init_script(struct webview *w, const char *js,
+                                        int all_frames);
+WEBVIEW_API void webview_clear_init_scripts(struct webview *w);
+WEBVIEW_API int webview_add_style_sheet(struct webview *w, const char *css);
+WEBVIEW_API int webview_clear_style_sheets(struct webview *w);
 WEBVIEW_API void webview_set_title(struct webview *w, const char *title);
 WEBVIEW_API void webview_set_fullscreen(struct webview *w, int fullscreen);
 WEBVIEW_API void webview_set_color(struct webview *w, uint8_t r, uint8_t g,
@@ -167,6 +241,31 @@ WEBVIEW_API void webview_dialog(struct webview *w,
                                 char *result, size_t resultsz);
 WEBVIEW_API void webview_dispatch(struct webview *w, webview_dispatch_fn fn,
                                   void *arg);
+WEBVIEW_API void webview_navigate(struct webview *w, const char *url);
+WEBVIEW_API int webview_set_html(struct webview *w, const char *html,
+                                 const char *base_url);
+WEBVIEW_API void webview_reload(struct webview *w);
+WEBVIEW_API void webview_go_back(struct webview *w);
+WEBVIEW_API void webview_go_forward(struct webview *w);
+WEBVIEW_API void webview_stop(struct webview *w);
+WEBVIEW_API void webview_get_url(struct webview *w, char *url, size_t urlsz);
+/* The URL, the method and the headers ("Name: value" lines, to be freed) of
+   a scheme task are only available from the scheme callback. The other
+   functions may be called from any thread, the body of the response is
+   streamed until the task is finished. */
+WEBVIEW_API const char *
+webview_scheme_task_url(struct webview_scheme_task *task);
+WEBVIEW_API const char *
+webview_scheme_task_method(struct webview_scheme_task *task);
+WEBVIEW_API char *webview_scheme_task_headers(struct webview_scheme_task *task);
+WEBVIEW_API int webview_scheme_task_read(struct webview_scheme_task *task,
+                                         char *buf, size_t sz);
+WEBVIEW_API int webview_scheme_task_respond(struct webview_scheme_task *task,
+                                            int status, const char *headers);
+WEBVIEW_API int webview_scheme_task_write(struct webview_scheme_task *task,
+                                          const char *buf, size_t sz);
+WEBVIEW_API void webview_scheme_task_finish(struct webview_scheme_task *task,
+                                            const char *err);
 WEBVIEW_API void webview_terminate(struct webview *w);
 WEBVIEW_API void webview_exit(struct webview *w);
 WEBVIEW_API void webview_debug(const char *format, ...);
@@ -241,6 +340,29 @@ WEBVIEW_API int webview_inject_css(struct webview *w, const char *css) {
   return r;
 }
 
+#if !defined(WEBVIEW_WINAPI)
+/* Calls fn for every "Name: value" line of the headers, which are split in
+   place */
+static void webview_each_header(char *headers,
+                                void (*fn)(char *name, char *value,
+                                           void *arg),
+                                void *arg) {
+  char *line = headers;
+  while (line != NULL && *line != '\0') {
+    char *end = strchr(line, '\n');
+    if (end != NULL) {
+      *end = '\0';
+    }
+    char *sep = strstr(line, ": ");
+    if (sep != NULL) {
+      *sep = '\0';
+      fn(line, sep + 2, arg);
+    }
+    line = (end != NULL ? end + 1 : NULL);
+  }
+}
+#endif
+
 #if defined(WEBVIEW_GTK)
 static void external_message_received_cb(WebKitUserContentManager *m,
                                          WebKitJavascriptResult *r,
@@ -261,12 +383,170 @@ static void external_message_received_cb(WebKitUserContentManager *m,
   g_free(s);
 }
 
+struct webview_eval_result_arg {
+  struct webview *w;
+  webview_eval_result_fn fn;
+  void *arg;
+  char *js;
+};
+
+static void webview_eval_result_finished(GObject *object, GAsyncResult *result,
+                                         gpointer userdata) {
+  struct webview_eval_result_arg *arg =
+      (struct webview_eval_result_arg *)userdata;
+  GError *error = NULL;
+  WebKitJavascriptResult *r = webkit_web_view_run_javascript_finish(
+      WEBKIT_WEB_VIEW(object), result, &error);
+  if (r == NULL) {
+    if (arg->fn != NULL) {
+      arg->fn(arg->w, arg->arg, NULL, error->message);
+    }
+    g_error_free(error);
+  } else {
+    if (arg->fn != NULL) {
+      JSGlobalContextRef context =
+          webkit_javascript_result_get_global_context(r);
+      JSValueRef value = webkit_javascript_result_get_value(r);
+      JSStringRef js = JSValueToStringCopy(context, value, NULL);
+      size_t n = JSStringGetMaximumUTF8CStringSize(js);
+      char *s = g_new(char, n);
+      JSStringGetUTF8CString(js, s, n);
+      arg->fn(arg->w, arg->arg, s, NULL);
+      JSStringRelease(js);
+      g_free(s);
+    }
+    webkit_javascript_result_unref(r);
+  }
+  g_free(arg->js);
+  g_free(arg);
+}
+
+static void webview_load_notify(struct webview *w,
+                                enum webview_load_event event,
+                                const char *err) {
+  if (w->load_cb != NULL) {
+    WebKitWebView *webview = WEBKIT_WEB_VIEW(w->priv.webview);
+    const gchar *uri = webkit_web_view_get_uri(webview);
+    w->load_cb(w, event, uri != NULL ? uri : "", err,
+               webkit_web_view_get_estimated_load_progress(webview));
+  }
+}
+
+static gboolean webview_load_failed_cb(WebKitWebView *webview,
+                                       WebKitLoadEvent event,
+                                       gchar *failing_uri, GError *error,
+                                       gpointer arg) {
+  (void)webview;
+  (void)event;
+  struct webview *w = (struct webview *)arg;
+  /* The scripts waiting for the page would wait forever */
+  struct webview_eval_result_arg *context;
+  while ((context = (struct webview_eval_result_arg *)g_queue_pop_head(
+              w->priv.evals)) != NULL) {
+    if (context->fn != NULL) {
+      context->fn(w, context->arg, NULL, error->message);
+    }
+    g_free(context->js);
+    g_free(context);
+  }
+  if (w->load_cb != NULL) {
+    w->load_cb(w, WEBVIEW_LOAD_FAILED, failing_uri, error->message,
+               webkit_web_view_get_estimated_load_progress(webview));
+  }
+  return FALSE;
+}
+
+static void webview_load_progress_cb(GObject *object, GParamSpec *pspec,
+                                     gpointer arg) {
+  (void)object;
+  (void)pspec;
+  webview_load_notify((struct webview *)arg, WEBVIEW_LOAD_PROGRESS, NULL);
+}
+
 static void webview_load_changed_cb(WebKitWebView *webview,
                                     WebKitLoadEvent event, gpointer arg) {
   (void)webview;
   struct webview *w = (struct webview *)arg;
-  if (event == WEBKIT_LOAD_FINISHED) {
+  switch (event) {
+  case WEBKIT_LOAD_STARTED:
+    webview_load_notify(w, WEBVIEW_LOAD_STARTED, NULL);
+    break;
+  case WEBKIT_LOAD_REDIRECTED:
+    webview_load_notify(w, WEBVIEW_LOAD_REDIRECTED, NULL);
+    break;
+  case WEBKIT_LOAD_COMMITTED:
+    webview_load_notify(w, WEBVIEW_LOAD_COMMITTED, NULL);
+    break;
+  case WEBKIT_LOAD_FINISHED:
+    webview_load_notify(w, WEBVIEW_LOAD_FINISHED, NULL);
+    break;
+  }
+  if (event == WEBKIT_LOAD_STARTED) {
+    /* Scripts are evaluated in the new page once it is loaded */
+    w->priv.ready = 0;
+  } else if (event == WEBKIT_LOAD_FINISHED) {
     w->priv.ready = 1;
+    /* Run the scripts evaluated before the page was loaded, in order */
+    struct webview_eval_result_arg *context;
+    while ((context = (struct webview_eval_result_arg *)g_queue_pop_head(
+                w->priv.evals)) != NULL) {
+      webkit_web_view_run_javascript(WEBKIT_WEB_VIEW(w->priv.webview),
+                                     context->js, NULL,
+                                     webview_eval_result_finished, context);
+    }
+    if (w->ready_cb != NULL) {
+      w->ready_cb(w);
+    }
+  }
+}
+
+static gboolean webview_decide_policy_cb(WebKitWebView *webview,
+                                         WebKitPolicyDecision *decision,
+                                         WebKitPolicyDecisionType type,
+                                         gpointer arg) {
+  (void)webview;
+  struct webview *w = (struct webview *)arg;
+  if (w->navigation_cb == NULL ||
+      type == WEBKIT_POLICY_DECISION_TYPE_RESPONSE) {
+    return FALSE;
+  }
+  WebKitNavigationAction *action =
+      webkit_navigation_policy_decision_get_navigation_action(
+          WEBKIT_NAVIGATION_POLICY_DECISION(decision));
+  const char *uri =
+      webkit_uri_request_get_uri(webkit_navigation_action_get_request(action));
+  enum webview_navigation_type nav_type;
+  switch (webkit_navigation_action_get_navigation_type(action)) {
+  case WEBKIT_NAVIGATION_TYPE_LINK_CLICKED:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_LINK;
+    break;
+  case WEBKIT_NAVIGATION_TYPE_FORM_SUBMITTED:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_FORM_SUBMIT;
+    break;
+  case WEBKIT_NAVIGATION_TYPE_BACK_FORWARD:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_BACK_FORWARD;
+    break;
+  case WEBKIT_NAVIGATION_TYPE_RELOAD:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_RELOAD;
+    break;
+  case WEBKIT_NAVIGATION_TYPE_FORM_RESUBMITTED:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_FORM_RESUBMIT;
+    break;
+  default:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_OTHER;
+  }
+  switch (w->navigation_cb(w, uri, nav_type,
+                           type == WEBKIT_POLICY_DECISION_TYPE_NEW_WINDOW_ACTION)) {
+  case WEBVIEW_NAVIGATION_BLOCK:
+    webkit_policy_decision_ignore(decision);
+    return TRUE;
+  case WEBVIEW_NAVIGATION_OPEN_EXTERNAL:
+    gtk_show_uri_on_window(GTK_WINDOW(w->priv.window), uri, GDK_CURRENT_TIME,
+                           NULL);
+    webkit_policy_decision_ignore(decision);
+    return TRUE;
+  default:
+    return FALSE;
   }
 }
 
@@ -289,6 +569,82 @@ static gboolean webview_context_menu_cb(WebKitWebView *webview,
   return TRUE;
 }
 
+WEBVIEW_API int webview_add_init_script(struct webview *w, const char *js,
+                                        int all_frames) {
+  WebKitUserContentManager *m = webkit_web_view_get_user_content_manager(
+      WEBKIT_WEB_VIEW(w->priv.webview));
+  WebKitUserScript *script = webkit_user_script_new(
+      js,
+      all_frames ? WEBKIT_USER_CONTENT_INJECT_ALL_FRAMES
+                 : WEBKIT_USER_CONTENT_INJECT_TOP_FRAME,
+      WEBKIT_USER_SCRIPT_INJECT_AT_DOCUMENT_START, NULL, NULL);
+  webkit_user_content_manager_add_script(m, script);
+  webkit_user_script_unref(script);
+  return 0;
+}
+
+WEBVIEW_API void webview_clear_init_scripts(struct webview *w) {
+  WebKitUserContentManager *m = webkit_web_view_get_user_content_manager(
+      WEBKIT_WEB_VIEW(w->priv.webview));
+  webkit_user_content_manager_remove_all_scripts(m);
+  webview_add_init_script(
+      w,
+      "window.external={invoke:function(x){"
+      "window.webkit.messageHandlers.external.postMessage(x);}}",
+      0);
+}
+
+WEBVIEW_API int webview_add_style_sheet(struct webview *w, const char *css) {
+  WebKitUserContentManager *m = webkit_web_view_get_user_content_manager(
+      WEBKIT_WEB_VIEW(w->priv.webview));
+  WebKitUserStyleSheet *sheet = webkit_user_style_sheet_new(
+      css, WEBKIT_USER_CONTENT_INJECT_ALL_FRAMES, WEBKIT_USER_STYLE_LEVEL_USER,
+      NULL, NULL);
+  webkit_user_content_manager_add_style_sheet(m, sheet);
+  webkit_user_style_sheet_unref(sheet);
+  return 0;
+}
+
+WEBVIEW_API int webview_clear_style_sheets(struct webview *w) {
+  WebKitUserContentManager *m = webkit_web_view_get_user_content_manager(
+      WEBKIT_WEB_VIEW(w->priv.webview));
+  webkit_user_content_manager_remove_all_style_sheets(m);
+  return 0;
+}
+
+struct webview_scheme_task {
+  struct webview *w;
+  WebKitURISchemeRequest *request;
+  GInputStream *body;
+  GInputStream *response;
+  int out;
+  int status;
+  char *headers;
+  const char *content_type;
+#if WEBKIT_CHECK_VERSION(2, 36, 0)
+  SoupMessageHeaders *response_headers;
+#endif
+  char *err;
+  /* Set on the main thread once the response is sent, or once the task is
+     cancelled because the webview is closed */
+  int responded;
+  int cancelled;
+};
+
+static void webview_scheme_request_cb(WebKitURISchemeRequest *request,
+                                      gpointer arg) {
+  struct webview *w = (struct webview *)arg;
+  struct webview_scheme_task *t = g_new0(struct webview_scheme_task, 1);
+  t->w = w;
+  t->request = WEBKIT_URI_SCHEME_REQUEST(g_object_ref(request));
+  t->out = -1;
+#if WEBKIT_CHECK_VERSION(2, 40, 0)
+  t->body = webkit_uri_scheme_request_get_http_body(request);
+#endif
+  w->priv.scheme_tasks = g_list_prepend(w->priv.scheme_tasks, t);
+  w->scheme_cb(w, t);
+}
+
 WEBVIEW_API int webview_init(struct webview *w) {
   if (gtk_init_check(0, NULL) == FALSE) {
     return -1;
@@ -297,6 +653,9 @@ WEBVIEW_API int webview_init(struct webview *w) {
   w->priv.ready = 0;
   w->priv.should_exit = 0;
   w->priv.queue = g_async_queue_new();
+  w->priv.evals = g_queue_new();
+  w->priv.context = NULL;
+  w->priv.scheme_tasks = NULL;
   w->priv.window = gtk_window_new(GTK_WINDOW_TOPLEVEL);
   gtk_window_set_title(GTK_WINDOW(w->priv.window), w->title);
 
@@ -317,11 +676,38 @@ WEBVIEW_API int webview_init(struct webview *w) {
   g_signal_connect(m, "script-message-received::external",
                    G_CALLBACK(external_message_received_cb), w);
 
-  w->priv.webview = webkit_web_view_new_with_user_content_manager(m);
+  /* Custom schemes are registered on a context of their own, so that every
+     webview has its own handlers */
+  WebKitWebContext *context = webkit_web_context_get_default();
+  if (w->schemes != NULL && w->schemes[0] != NULL) {
+    context = webkit_web_context_new();
+    w->priv.context = context;
+    WebKitSecurityManager *security =
+        webkit_web_context_get_security_manager(context);
+    const char **scheme;
+    for (scheme = w->schemes; *scheme != NULL; scheme++) {
+      webkit_web_context_register_uri_scheme(context, *scheme,
+                                             webview_scheme_request_cb, w, NULL);
+      webkit_security_manager_register_uri_scheme_as_secure(security, *scheme);
+      webkit_security_manager_register_uri_scheme_as_cors_enabled(security,
+                                                                  *scheme);
+    }
+  }
+  w->priv.webview = GTK_WIDGET(g_object_new(WEBKIT_TYPE_WEB_VIEW,
+                                            "web-context", context,
+                                            "user-content-manager", m, NULL));
+  webview_clear_init_scripts(w);
   webkit_web_view_load_uri(WEBKIT_WEB_VIEW(w->priv.webview),
                            webview_check_url(w->url));
   g_signal_connect(G_OBJECT(w->priv.webview), "load-changed",
                    G_CALLBACK(webview_load_changed_cb), w);
+  g_signal_connect(G_OBJECT(w->priv.webview), "decide-policy",
+                   G_CALLBACK(webview_decide_policy_cb), w);
+  g_signal_connect(G_OBJECT(w->priv.webview), "load-failed",
+                   G_CALLBACK(webview_load_failed_cb), w);
+  g_signal_connect(G_OBJECT(w->priv.webview),
+                   "notify::estimated-load-progress",
+                   G_CALLBACK(webview_load_progress_cb), w);
   gtk_container_add(GTK_CONTAINER(w->priv.scroller), w->priv.webview);
 
   if (w->debug) {
@@ -336,12 +722,6 @@ WEBVIEW_API int webview_init(struct webview *w) {
 
   gtk_widget_show_all(w->priv.window);
 
-  webkit_web_view_run_javascript(
-      WEBKIT_WEB_VIEW(w->priv.webview),
-      "window.external={invoke:function(x){"
-      "window.webkit.messageHandlers.external.postMessage(x);}}",
-      NULL, NULL, NULL);
-
   g_signal_connect(G_OBJECT(w->priv.window), "destroy",
                    G_CALLBACK(webview_destroy_cb), w);
   return 0;
@@ -425,27 +805,31 @@ WEBVIEW_API void webview_dialog(struct webview *w,
   }
 }
 
-static void webview_eval_finished(GObject *object, GAsyncResult *result,
-                                  gpointer userdata) {
-  (void)object;
-  (void)result;
-  struct webview *w = (struct webview *)userdata;
-  w->priv.js_busy = 0;
-}
-
-WEBVIEW_API int webview_eval(struct webview *w, const char *js) {
-  while (w->priv.ready == 0) {
-    g_main_context_iteration(NULL, TRUE);
+WEBVIEW_API int webview_eval_result(struct webview *w, const char *js,
+                                    webview_eval_result_fn fn, void *arg) {
+  struct webview_eval_result_arg *context =
+      (struct webview_eval_result_arg *)g_new(struct webview_eval_result_arg,
+                                              1);
+  context->w = w;
+  context->fn = fn;
+  context->arg = arg;
+  context->js = g_strdup(js);
+  /* Never iterate the main loop here, otherwise other callbacks could be
+     called recursively. Scripts are queued until the page is loaded and
+     the result is reported asynchronously. */
+  if (w->priv.ready == 0) {
+    g_queue_push_tail(w->priv.evals, context);
+    return 0;
   }
-  w->priv.js_busy = 1;
   webkit_web_view_run_javascript(WEBKIT_WEB_VIEW(w->priv.webview), js, NULL,
-                                 webview_eval_finished, w);
-  while (w->priv.js_busy) {
-    g_main_context_iteration(NULL, TRUE);
-  }
+                                 webview_eval_result_finished, context);
   return 0;
 }
 
+WEBVIEW_API int webview_eval(struct webview *w, const char *js) {
+  return webview_eval_result(w, js, NULL, NULL);
+}
+
 static gboolean webview_dispatch_wrapper(gpointer userdata) {
   struct webview *w = (struct webview *)userdata;
   for (;;) {
@@ -475,11 +859,210 @@ WEBVIEW_API void webview_dispatch(struct webview *w, webview_dispatch_fn fn,
   g_async_queue_unlock(w->priv.queue);
 }
 
+WEBVIEW_API void webview_navigate(struct webview *w, const char *url) {
+  webkit_web_view_load_uri(WEBKIT_WEB_VIEW(w->priv.webview),
+                           webview_check_url(url));
+}
+
+WEBVIEW_API int webview_set_html(struct webview *w, const char *html,
+                                 const char *base_url) {
+  webkit_web_view_load_html(WEBKIT_WEB_VIEW(w->priv.webview), html,
+                            (base_url != NULL && *base_url) ? base_url : NULL);
+  return 0;
+}
+
+WEBVIEW_API void webview_reload(struct webview *w) {
+  webkit_web_view_reload(WEBKIT_WEB_VIEW(w->priv.webview));
+}
+
+WEBVIEW_API void webview_go_back(struct webview *w) {
+  webkit_web_view_go_back(WEBKIT_WEB_VIEW(w->priv.webview));
+}
+
+WEBVIEW_API void webview_go_forward(struct webview *w) {
+  webkit_web_view_go_forward(WEBKIT_WEB_VIEW(w->priv.webview));
+}
+
+WEBVIEW_API void webview_stop(struct webview *w) {
+  webkit_web_view_stop_loading(WEBKIT_WEB_VIEW(w->priv.webview));
+}
+
+WEBVIEW_API void webview_get_url(struct webview *w, char *url, size_t urlsz) {
+  const gchar *uri = webkit_web_view_get_uri(WEBKIT_WEB_VIEW(w->priv.webview));
+  g_strlcpy(url, uri != NULL ? uri : "", urlsz);
+}
+
+WEBVIEW_API const char *
+webview_scheme_task_url(struct webview_scheme_task *task) {
+  return webkit_uri_scheme_request_get_uri(task->request);
+}
+
+WEBVIEW_API const char *
+webview_scheme_task_method(struct webview_scheme_task *task) {
+#if WEBKIT_CHECK_VERSION(2, 12, 0)
+  const char *method = webkit_uri_scheme_request_get_http_method(task->request);
+  if (method != NULL) {
+    return method;
+  }
+#endif
+  return "GET";
+}
+
+WEBVIEW_API char *webview_scheme_task_headers(struct webview_scheme_task *task) {
+  GString *s = g_string_new(NULL);
+#if WEBKIT_CHECK_VERSION(2, 36, 0)
+  SoupMessageHeaders *headers =
+      webkit_uri_scheme_request_get_http_headers(task->request);
+  if (headers != NULL) {
+    SoupMessageHeadersIter iter;
+    const char *name, *value;
+    soup_message_headers_iter_init(&iter, headers);
+    while (soup_message_headers_iter_next(&iter, &name, &value)) {
+      g_string_append_printf(s, "%s: %s\n", name, value);
+    }
+  }
+#else
+  (void)task;
+#endif
+  char *result = strdup(s->str);
+  g_string_free(s, TRUE);
+  return result;
+}
+
+WEBVIEW_API int webview_scheme_task_read(struct webview_scheme_task *task,
+                                         char *buf, size_t sz) {
+  if (task->body == NULL) {
+    return 0;
+  }
+  gssize n = g_input_stream_read(task->body, buf, sz, NULL, NULL);
+  return n < 0 ? -1 : (int)n;
+}
+
+static void webview_scheme_header(char *name, char *value, void *arg) {
+  struct webview_scheme_task *t = (struct webview_scheme_task *)arg;
+  if (g_ascii_strcasecmp(name, "Content-Type") == 0) {
+    t->content_type = value;
+  }
+#if WEBKIT_CHECK_VERSION(2, 36, 0)
+  soup_message_headers_append(t->response_headers, name, value);
+#endif
+}
+
+static void webview_scheme_respond_cb(struct webview *w, void *arg) {
+  (void)w;
+  struct webview_scheme_task *t = (struct webview_scheme_task *)arg;
+  if (t->cancelled) {
+    /* Closing the stream fails the writes of the handler */
+    g_object_unref(t->response);
+    t->response = NULL;
+    return;
+  }
+  t->responded = 1;
+#if WEBKIT_CHECK_VERSION(2, 36, 0)
+  t->response_headers = soup_message_headers_new(SOUP_MESSAGE_HEADERS_RESPONSE);
+#endif
+  webview_each_header(t->headers, webview_scheme_header, t);
+#if WEBKIT_CHECK_VERSION(2, 36, 0)
+  WebKitURISchemeResponse *response =
+      webkit_uri_scheme_response_new(t->response, -1);
+  webkit_uri_scheme_response_set_status(response, t->status, NULL);
+  if (t->content_type != NULL) {
+    webkit_uri_scheme_response_set_content_type(response, t->content_type);
+  }
+  webkit_uri_scheme_response_set_http_headers(response, t->response_headers);
+  webkit_uri_scheme_request_finish_with_response(t->request, response);
+  g_object_unref(response);
+#else
+  webkit_uri_scheme_request_finish(t->request, t->response, -1,
+                                   t->content_type);
+#endif
+  g_object_unref(t->response);
+  t->response = NULL;
+}
+
+WEBVIEW_API int webview_scheme_task_respond(struct webview_scheme_task *task,
+                                            int status, const char *headers) {
+  int fds[2];
+  if (socketpair(AF_UNIX, SOCK_STREAM, 0, fds) != 0) {
+    return -1;
+  }
+  task->out = fds[1];
+  task->response = g_unix_input_stream_new(fds[0], TRUE);
+  task->status = status;
+  task->headers = strdup(headers);
+  webview_dispatch(task->w, webview_scheme_respond_cb, task);
+  return 0;
+}
+
+WEBVIEW_API int webview_scheme_task_write(struct webview_scheme_task *task,
+                                          const char *buf, size_t sz) {
+  while (sz > 0) {
+    ssize_t n = send(task->out, buf, sz, MSG_NOSIGNAL);
+    if (n < 0) {
+      if (errno == EINTR) {
+        continue;
+      }
+      return -1;
+    }
+    buf += n;
+    sz -= n;
+  }
+  return 0;
+}
+
+static void webview_scheme_finish_cb(struct webview *w, void *arg) {
+  struct webview_scheme_task *t = (struct webview_scheme_task *)arg;
+  w->priv.scheme_tasks = g_list_remove(w->priv.scheme_tasks, t);
+  if (t->responded == 0 && t->cancelled == 0) {
+    GError *err = g_error_new_literal(G_IO_ERROR, G_IO_ERROR_FAILED,
+                                      t->err != NULL ? t->err : "no response");
+    webkit_uri_scheme_request_finish_error(t->request, err);
+    g_error_free(err);
+  }
+  g_object_unref(t->request);
+  if (t->body != NULL) {
+    g_object_unref(t->body);
+  }
+  free(t->headers);
+  free(t->err);
+  g_free(t);
+}
+
+WEBVIEW_API void webview_scheme_task_finish(struct webview_scheme_task *task,
+                                            const char *err) {
+  /* Closing the socket ends the body of the response */
+  if (task->out >= 0) {
+    close(task->out);
+  }
+  task->err = (err != NULL ? strdup(err) : NULL);
+  webview_dispatch(task->w, webview_scheme_finish_cb, task);
+}
+
 WEBVIEW_API void webview_terminate(struct webview *w) {
   w->priv.should_exit = 1;
 }
 
-WEBVIEW_API void webview_exit(struct webview *w) { (void)w; }
+WEBVIEW_API void webview_exit(struct webview *w) {
+  /* The pending scheme tasks are failed now, they are only freed once their
+     handlers finish them */
+  GList *l;
+  for (l = w->priv.scheme_tasks; l != NULL; l = l->next) {
+    struct webview_scheme_task *t = (struct webview_scheme_task *)l->data;
+    if (t->responded == 0) {
+      GError *err = g_error_new_literal(G_IO_ERROR, G_IO_ERROR_CANCELLED,
+                                        "the webview is closed");
+      webkit_uri_scheme_request_finish_error(t->request, err);
+      g_error_free(err);
+    }
+    t->cancelled = 1;
+  }
+  g_list_free(w->priv.scheme_tasks);
+  w->priv.scheme_tasks = NULL;
+  if (w->priv.context != NULL) {
+    g_object_unref(w->priv.context);
+    w->priv.context = NULL;
+  }
+}
 WEBVIEW_API void webview_print_log(const char *s) {
   fprintf(stderr, "%s\n", s);
 }
@@ -1100,7 +1683,10 @@ error:
 }
 
 #define WEBVIEW_DATA_URL_PREFIX "data:text/html,"
-static int DisplayHTMLPage(struct webview *w) {
+/* Navigates to the URL, or writes the HTML into a blank page if html is not
+   NULL */
+static int DisplayHTMLPage(struct webview *w, const char *webview_url,
+                           const char *html) {
   IWebBrowser2 *webBrowser2;
   VARIANT myURL;
   LPDISPATCH lpDispatch;
@@ -1111,12 +1697,11 @@ static int DisplayHTMLPage(struct webview *w) {
   VARIANT *pVar;
   browserObject = *w->priv.browser;
   int isDataURL = 0;
-  const char *webview_url = webview_check_url(w->url);
   if (!browserObject->lpVtbl->QueryInterface(
           browserObject, iid_unref(&IID_IWebBrowser2), (void **)&webBrowser2)) {
     LPCSTR webPageName;
-    isDataURL = (strncmp(webview_url, WEBVIEW_DATA_URL_PREFIX,
-                         strlen(WEBVIEW_DATA_URL_PREFIX)) == 0);
+    isDataURL = html != NULL || (strncmp(webview_url, WEBVIEW_DATA_URL_PREFIX,
+                                         strlen(WEBVIEW_DATA_URL_PREFIX)) == 0);
     if (isDataURL) {
       webPageName = "about:blank";
     } else {
@@ -1147,13 +1732,18 @@ static int DisplayHTMLPage(struct webview *w) {
       return 0;
     }
 
-    char *url = (char *)calloc(1, strlen(webview_url) + 1);
-    char *q = url;
-    for (const char *p = webview_url + strlen(WEBVIEW_DATA_URL_PREFIX); *q = *p;
-         p++, q++) {
-      if (*q == '%' && *(p + 1) && *(p + 2)) {
-        sscanf(p + 1, "%02x", q);
-        p = p + 2;
+    char *url;
+    if (html != NULL) {
+      url = strdup(html);
+    } else {
+      url = (char *)calloc(1, strlen(webview_url) + 1);
+      char *q = url;
+      for (const char *p = webview_url + strlen(WEBVIEW_DATA_URL_PREFIX);
+           *q = *p; p++, q++) {
+        if (*q == '%' && *(p + 1) && *(p + 2)) {
+          sscanf(p + 1, "%02x", q);
+          p = p + 2;
+        }
       }
     }
 
@@ -1313,7 +1903,7 @@ WEBVIEW_API int webview_init(struct webview *w) {
 
   SetWindowLongPtr(w->priv.hwnd, GWLP_USERDATA, (LONG_PTR)w);
 
-  DisplayHTMLPage(w);
+  DisplayHTMLPage(w, webview_check_url(w->url), NULL);
 
   SetWindowText(w->priv.hwnd, w->title);
   ShowWindow(w->priv.hwnd, SW_SHOWDEFAULT);
@@ -1323,6 +1913,81 @@ WEBVIEW_API int webview_init(struct webview *w) {
   return 0;
 }
 
+/* MSHTML has no user scripts, they are evaluated once the document is
+   complete instead */
+WEBVIEW_API int webview_add_init_script(struct webview *w, const char *js,
+                                        int all_frames) {
+  (void)all_frames;
+  char **scripts = (char **)realloc(
+      w->priv.init_scripts, (w->priv.init_scripts_len + 1) * sizeof(char *));
+  if (scripts == NULL) {
+    return -1;
+  }
+  w->priv.init_scripts = scripts;
+  w->priv.init_scripts[w->priv.init_scripts_len++] = strdup(js);
+  return 0;
+}
+
+WEBVIEW_API void webview_clear_init_scripts(struct webview *w) {
+  for (int i = 0; i < w->priv.init_scripts_len; i++) {
+    free(w->priv.init_scripts[i]);
+  }
+  free(w->priv.init_scripts);
+  w->priv.init_scripts = NULL;
+  w->priv.init_scripts_len = 0;
+}
+
+/* MSHTML has no user style sheets */
+WEBVIEW_API int webview_add_style_sheet(struct webview *w, const char *css) {
+  (void)w;
+  (void)css;
+  return -1;
+}
+
+WEBVIEW_API int webview_clear_style_sheets(struct webview *w) {
+  (void)w;
+  return -1;
+}
+
+/* MSHTML has no load events without implementing DWebBrowserEvents2, so the
+   ready state of the document is checked on every iteration of the loop.
+   Redirects, failures and progress are not reported. */
+static void webview_check_ready(struct webview *w) {
+  IWebBrowser2 *webBrowser2;
+  READYSTATE state = READYSTATE_UNINITIALIZED;
+  IOleObject *browser = *w->priv.browser;
+  if (browser->lpVtbl->QueryInterface(browser, iid_unref(&IID_IWebBrowser2),
+                                      (void **)&webBrowser2) != S_OK) {
+    return;
+  }
+  webBrowser2->lpVtbl->get_ReadyState(webBrowser2, &state);
+  webBrowser2->lpVtbl->Release(webBrowser2);
+  if (state != w->priv.ready_state && w->load_cb != NULL) {
+    char url[4096];
+    webview_get_url(w, url, sizeof(url));
+    if (state == READYSTATE_LOADING &&
+        w->priv.ready_state != READYSTATE_INTERACTIVE) {
+      w->load_cb(w, WEBVIEW_LOAD_STARTED, url, NULL, 0.1);
+    } else if (state == READYSTATE_INTERACTIVE) {
+      w->load_cb(w, WEBVIEW_LOAD_COMMITTED, url, NULL, 0.5);
+    } else if (state == READYSTATE_COMPLETE) {
+      w->load_cb(w, WEBVIEW_LOAD_FINISHED, url, NULL, 1.0);
+    }
+  }
+  w->priv.ready_state = state;
+  if (state != READYSTATE_COMPLETE) {
+    w->priv.ready = 0;
+  } else if (!w->priv.ready) {
+    w->priv.ready = 1;
+    for (int i = 0; i < w->priv.init_scripts_len; i++) {
+      webview_eval(w, w->priv.init_scripts[i]);
+    }
+    if (w->ready_cb != NULL) {
+      w->ready_cb(w);
+    }
+  }
+}
+
 WEBVIEW_API int webview_loop(struct webview *w, int blocking) {
   MSG msg;
   if (blocking) {
@@ -1330,6 +1995,7 @@ WEBVIEW_API int webview_loop(struct webview *w, int blocking) {
   } else {
     PeekMessage(&msg, 0, 0, 0, PM_REMOVE);
   }
+  webview_check_ready(w);
   switch (msg.message) {
   case WM_QUIT:
     return -1;
@@ -1361,7 +2027,8 @@ WEBVIEW_API int webview_loop(struct webview *w, int blocking) {
   return 0;
 }
 
-WEBVIEW_API int webview_eval(struct webview *w, const char *js) {
+static int webview_script_eval(struct webview *w, const char *js,
+                               VARIANT *result) {
   IWebBrowser2 *webBrowser2;
   IHTMLDocument2 *htmlDoc2;
   IDispatch *docDispatch;
@@ -1396,36 +2063,66 @@ WEBVIEW_API int webview_eval(struct webview *w, const char *js) {
 
   DISPPARAMS params;
   VARIANT arg;
-  VARIANT result;
   EXCEPINFO excepInfo;
   UINT nArgErr = (UINT)-1;
   params.cArgs = 1;
   params.cNamedArgs = 0;
   params.rgvarg = &arg;
   arg.vt = VT_BSTR;
-  static const char *prologue = "(function(){";
-  static const char *epilogue = ";})();";
-  int n = strlen(prologue) + strlen(epilogue) + strlen(js) + 1;
-  char *eval = (char *)malloc(n);
-  snprintf(eval, n, "%s%s%s", prologue, js, epilogue);
-  wchar_t *buf = webview_to_utf16(eval);
+  wchar_t *buf = webview_to_utf16(js);
   if (buf == NULL) {
     return -1;
   }
   arg.bstrVal = SysAllocString(buf);
+  GlobalFree(buf);
   if (scriptDispatch->lpVtbl->Invoke(
           scriptDispatch, dispid, iid_unref(&IID_NULL), 0, DISPATCH_METHOD,
-          &params, &result, &excepInfo, &nArgErr) != S_OK) {
+          &params, result, &excepInfo, &nArgErr) != S_OK) {
     return -1;
   }
   SysFreeString(arg.bstrVal);
-  free(eval);
   scriptDispatch->lpVtbl->Release(scriptDispatch);
   htmlDoc2->lpVtbl->Release(htmlDoc2);
   docDispatch->lpVtbl->Release(docDispatch);
   return 0;
 }
 
+WEBVIEW_API int webview_eval(struct webview *w, const char *js) {
+  VARIANT result;
+  static const char *prologue = "(function(){";
+  static const char *epilogue = ";})();";
+  int n = strlen(prologue) + strlen(epilogue) + strlen(js) + 1;
+  char *eval = (char *)malloc(n);
+  snprintf(eval, n, "%s%s%s", prologue, js, epilogue);
+  VariantInit(&result);
+  int r = webview_script_eval(w, eval, &result);
+  VariantClear(&result);
+  free(eval);
+  return r;
+}
+
+WEBVIEW_API int webview_eval_result(struct webview *w, const char *js,
+                                    webview_eval_result_fn fn, void *arg) {
+  VARIANT result;
+  VariantInit(&result);
+  if (fn == NULL) {
+    int r = webview_script_eval(w, js, &result);
+    VariantClear(&result);
+    return r;
+  }
+  if (webview_script_eval(w, js, &result) != 0) {
+    fn(w, arg, NULL, "evaluation failed");
+  } else if (result.vt != VT_BSTR) {
+    fn(w, arg, NULL, "unexpected result type");
+  } else {
+    char *s = webview_from_utf16(result.bstrVal);
+    fn(w, arg, s, NULL);
+    GlobalFree(s);
+  }
+  VariantClear(&result);
+  return 0;
+}
+
 WEBVIEW_API void webview_dispatch(struct webview *w, webview_dispatch_fn fn,
                                   void *arg) {
   PostMessageW(w->priv.hwnd, WM_WEBVIEW_DISPATCH, (WPARAM)fn, (LPARAM)arg);
@@ -1652,6 +2349,102 @@ WEBVIEW_API void webview_dialog(struct webview *w,
   }
 }
 
+WEBVIEW_API void webview_navigate(struct webview *w, const char *url) {
+  DisplayHTMLPage(w, webview_check_url(url), NULL);
+}
+
+WEBVIEW_API int webview_set_html(struct webview *w, const char *html,
+                                 const char *base_url) {
+  /* The HTML is written into a blank page, base_url is not supported */
+  (void)base_url;
+  return DisplayHTMLPage(w, "about:blank", html);
+}
+
+/* Calls one of the navigation methods of IWebBrowser2 */
+#define WEBVIEW_BROWSER_CALL(w, method)                                        \
+  do {                                                                         \
+    IWebBrowser2 *webBrowser2;                                                 \
+    IOleObject *browser = *(w)->priv.browser;                                  \
+    if (browser->lpVtbl->QueryInterface(browser,                               \
+                                        iid_unref(&IID_IWebBrowser2),          \
+                                        (void **)&webBrowser2) == S_OK) {      \
+      webBrowser2->lpVtbl->method(webBrowser2);                                \
+      webBrowser2->lpVtbl->Release(webBrowser2);                               \
+    }                                                                          \
+  } while (0)
+
+WEBVIEW_API void webview_reload(struct webview *w) {
+  WEBVIEW_BROWSER_CALL(w, Refresh);
+}
+
+WEBVIEW_API void webview_go_back(struct webview *w) {
+  WEBVIEW_BROWSER_CALL(w, GoBack);
+}
+
+WEBVIEW_API void webview_go_forward(struct webview *w) {
+  WEBVIEW_BROWSER_CALL(w, GoForward);
+}
+
+WEBVIEW_API void webview_stop(struct webview *w) {
+  WEBVIEW_BROWSER_CALL(w, Stop);
+}
+
+WEBVIEW_API void webview_get_url(struct webview *w, char *url, size_t urlsz) {
+  IWebBrowser2 *webBrowser2;
+  BSTR location = NULL;
+  IOleObject *browser = *w->priv.browser;
+  url[0] = '\0';
+  if (browser->lpVtbl->QueryInterface(browser, iid_unref(&IID_IWebBrowser2),
+                                      (void **)&webBrowser2) != S_OK) {
+    return;
+  }
+  if (webBrowser2->lpVtbl->get_LocationURL(webBrowser2, &location) == S_OK &&
+      location != NULL) {
+    char *s = webview_from_utf16(location);
+    if (s != NULL) {
+      strncpy(url, s, urlsz);
+      url[urlsz - 1] = '\0';
+      GlobalFree(s);
+    }
+    SysFreeString(location);
+  }
+  webBrowser2->lpVtbl->Release(webBrowser2);
+}
+
+/* Custom URI schemes are not supported by MSHTML, the scheme callback is
+   never called */
+WEBVIEW_API const char *
+webview_scheme_task_url(struct webview_scheme_task *task) {
+  return "";
+}
+
+WEBVIEW_API const char *
+webview_scheme_task_method(struct webview_scheme_task *task) {
+  return "GET";
+}
+
+WEBVIEW_API char *webview_scheme_task_headers(struct webview_scheme_task *task) {
+  return (char *)calloc(1, 1);
+}
+
+WEBVIEW_API int webview_scheme_task_read(struct webview_scheme_task *task,
+                                         char *buf, size_t sz) {
+  return -1;
+}
+
+WEBVIEW_API int webview_scheme_task_respond(struct webview_scheme_task *task,
+                                            int status, const char *headers) {
+  return -1;
+}
+
+WEBVIEW_API int webview_scheme_task_write(struct webview_scheme_task *task,
+                                          const char *buf, size_t sz) {
+  return -1;
+}
+
+WEBVIEW_API void webview_scheme_task_finish(struct webview_scheme_task *task,
+                                            const char *err) {}
+
 WEBVIEW_API void webview_terminate(struct webview *w) { PostQuitMessage(0); }
 
 WEBVIEW_API void webview_exit(struct webview *w) {
@@ -1683,6 +2476,13 @@ WEBVIEW_API void webview_print_log(const char *s) { OutputDebugString(s); }
 #define NSModalResponseOK 1
 #define WKNavigationActionPolicyDownload 2
 #define WKNavigationResponsePolicyAllow 1
+#define WKNavigationActionPolicyCancel 0
+#define WKNavigationActionPolicyAllow 1
+#define WKNavigationTypeLinkActivated 0
+#define WKNavigationTypeFormSubmitted 1
+#define WKNavigationTypeBackForward 2
+#define WKNavigationTypeReload 3
+#define WKNavigationTypeFormResubmitted 4
 #define WKUserScriptInjectionTimeAtDocumentStart 0
 #define NSApplicationActivationPolicyRegular 0
 
@@ -1809,6 +2609,167 @@ static void download_failed(id self, SEL cmd, id download, id error) {
              sel_registerName("UTF8String")));
 }
 
+static void webview_load_notify(struct webview *w, id webView,
+                                enum webview_load_event event, id error) {
+  if (w == NULL || w->load_cb == NULL) {
+    return;
+  }
+  const char *url = "";
+  id nsURL = objc_msgSend(webView, sel_registerName("URL"));
+  if (nsURL != NULL) {
+    url = (const char *)objc_msgSend(
+        objc_msgSend(nsURL, sel_registerName("absoluteString")),
+        sel_registerName("UTF8String"));
+  }
+  const char *err = NULL;
+  if (error != NULL) {
+    err = (const char *)objc_msgSend(
+        objc_msgSend(error, sel_registerName("localizedDescription")),
+        sel_registerName("UTF8String"));
+  }
+#if defined(__i386__) || defined(__x86_64__)
+  double progress = ((double (*)(id, SEL))objc_msgSend_fpret)(
+      webView, sel_registerName("estimatedProgress"));
+#else
+  double progress = ((double (*)(id, SEL))objc_msgSend)(
+      webView, sel_registerName("estimatedProgress"));
+#endif
+  w->load_cb(w, event, url, err, progress);
+}
+
+static void webview_did_start_navigation(id self, SEL cmd, id webView,
+                                         id navigation) {
+  webview_load_notify(
+      (struct webview *)objc_getAssociatedObject(self, "webview"), webView,
+      WEBVIEW_LOAD_STARTED, NULL);
+}
+
+static void webview_did_redirect_navigation(id self, SEL cmd, id webView,
+                                            id navigation) {
+  webview_load_notify(
+      (struct webview *)objc_getAssociatedObject(self, "webview"), webView,
+      WEBVIEW_LOAD_REDIRECTED, NULL);
+}
+
+static void webview_did_commit_navigation(id self, SEL cmd, id webView,
+                                          id navigation) {
+  webview_load_notify(
+      (struct webview *)objc_getAssociatedObject(self, "webview"), webView,
+      WEBVIEW_LOAD_COMMITTED, NULL);
+}
+
+static void webview_did_fail_navigation(id self, SEL cmd, id webView,
+                                        id navigation, id error) {
+  webview_load_notify(
+      (struct webview *)objc_getAssociatedObject(self, "webview"), webView,
+      WEBVIEW_LOAD_FAILED, error);
+}
+
+static void webview_did_finish_navigation(id self, SEL cmd, id webView,
+                                          id navigation) {
+  struct webview *w = (struct webview *)objc_getAssociatedObject(self, "webview");
+  webview_load_notify(w, webView, WEBVIEW_LOAD_FINISHED, NULL);
+  if (w != NULL && w->ready_cb != NULL) {
+    w->ready_cb(w);
+  }
+}
+
+struct webview_scheme_task {
+  struct webview *w;
+  id task;
+  id body;
+  size_t offset;
+  volatile int stopped;
+  int status;
+  char *headers;
+  char *err;
+};
+
+static void webview_start_scheme_task(id self, SEL cmd, id webView, id task) {
+  struct webview *w = (struct webview *)objc_getAssociatedObject(self, "webview");
+  struct webview_scheme_task *t =
+      (struct webview_scheme_task *)calloc(1, sizeof(*t));
+  t->w = w;
+  t->task = objc_msgSend(task, sel_registerName("retain"));
+  t->body = objc_msgSend(objc_msgSend(task, sel_registerName("request")),
+                         sel_registerName("HTTPBody"));
+  if (t->body != NULL) {
+    objc_msgSend(t->body, sel_registerName("retain"));
+  }
+  objc_setAssociatedObject(task, "webview_scheme_task", (id)(t),
+                           OBJC_ASSOCIATION_ASSIGN);
+  w->scheme_cb(w, t);
+}
+
+static void webview_stop_scheme_task(id self, SEL cmd, id webView, id task) {
+  struct webview_scheme_task *t = (struct webview_scheme_task *)
+      objc_getAssociatedObject(task, "webview_scheme_task");
+  if (t != NULL) {
+    t->stopped = 1;
+  }
+}
+
+/* Observes the estimatedProgress property of WKWebView */
+static void webview_observe_value(id self, SEL cmd, id keyPath, id object,
+                                  id change, void *context) {
+  webview_load_notify(
+      (struct webview *)objc_getAssociatedObject(self, "webview"), object,
+      WEBVIEW_LOAD_PROGRESS, NULL);
+}
+
+static void webview_decide_policy_for_navigation_action(
+    id self, SEL cmd, id webView, id navigationAction,
+    void (^decisionHandler)(int)) {
+  struct webview *w =
+      (struct webview *)objc_getAssociatedObject(self, "webview");
+  if (w == NULL || w->navigation_cb == NULL) {
+    decisionHandler(WKNavigationActionPolicyAllow);
+    return;
+  }
+  id url = objc_msgSend(
+      objc_msgSend(navigationAction, sel_registerName("request")),
+      sel_registerName("URL"));
+  const char *uri = (const char *)objc_msgSend(
+      objc_msgSend(url, sel_registerName("absoluteString")),
+      sel_registerName("UTF8String"));
+  enum webview_navigation_type nav_type;
+  switch ((long)objc_msgSend(navigationAction,
+                             sel_registerName("navigationType"))) {
+  case WKNavigationTypeLinkActivated:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_LINK;
+    break;
+  case WKNavigationTypeFormSubmitted:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_FORM_SUBMIT;
+    break;
+  case WKNavigationTypeBackForward:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_BACK_FORWARD;
+    break;
+  case WKNavigationTypeReload:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_RELOAD;
+    break;
+  case WKNavigationTypeFormResubmitted:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_FORM_RESUBMIT;
+    break;
+  default:
+    nav_type = WEBVIEW_NAVIGATION_TYPE_OTHER;
+  }
+  int new_window =
+      objc_msgSend(navigationAction, sel_registerName("targetFrame")) == NULL;
+  switch (w->navigation_cb(w, uri, nav_type, new_window)) {
+  case WEBVIEW_NAVIGATION_BLOCK:
+    decisionHandler(WKNavigationActionPolicyCancel);
+    break;
+  case WEBVIEW_NAVIGATION_OPEN_EXTERNAL:
+    objc_msgSend(objc_msgSend((id)objc_getClass("NSWorkspace"),
+                              sel_registerName("sharedWorkspace")),
+                 sel_registerName("openURL:"), url);
+    decisionHandler(WKNavigationActionPolicyCancel);
+    break;
+  default:
+    decisionHandler(WKNavigationActionPolicyAllow);
+  }
+}
+
 static void make_nav_policy_decision(id self, SEL cmd, id webView, id response,
                                      void (^decisionHandler)(int)) {
   if (objc_msgSend(response, sel_registerName("canShowMIMEType")) == 0) {
@@ -1818,6 +2779,61 @@ static void make_nav_policy_decision(id self, SEL cmd, id webView, id response,
   }
 }
 
+static void webview_add_user_script(id userController, const char *js,
+                                    int all_frames) {
+  id script = objc_msgSend((id)objc_getClass("WKUserScript"),
+                           sel_registerName("alloc"));
+  objc_msgSend(script,
+               sel_registerName("initWithSource:injectionTime:forMainFrameOnly:"),
+               get_nsstring(js), WKUserScriptInjectionTimeAtDocumentStart,
+               !all_frames);
+  objc_msgSend(userController, sel_registerName("addUserScript:"), script);
+  objc_msgSend(script, sel_registerName("release"));
+}
+
+static void webview_add_external_script(id userController) {
+  /***
+   In order to maintain compatibility with the other 'webviews' we need to
+   override window.external.invoke to call
+   webkit.messageHandlers.invoke.postMessage
+   ***/
+  webview_add_user_script(
+      userController,
+      "window.external = this; invoke = function(arg){ "
+      "webkit.messageHandlers.invoke.postMessage(arg); };",
+      1);
+}
+
+static id webview_user_content_controller(struct webview *w) {
+  return objc_msgSend(
+      objc_msgSend(w->priv.webview, sel_registerName("configuration")),
+      sel_registerName("userContentController"));
+}
+
+WEBVIEW_API int webview_add_init_script(struct webview *w, const char *js,
+                                        int all_frames) {
+  webview_add_user_script(webview_user_content_controller(w), js, all_frames);
+  return 0;
+}
+
+WEBVIEW_API void webview_clear_init_scripts(struct webview *w) {
+  id userController = webview_user_content_controller(w);
+  objc_msgSend(userController, sel_registerName("removeAllUserScripts"));
+  webview_add_external_script(userController);
+}
+
+/* WKUserContentController has no public API for user style sheets */
+WEBVIEW_API int webview_add_style_sheet(struct webview *w, const char *css) {
+  (void)w;
+  (void)css;
+  return -1;
+}
+
+WEBVIEW_API int webview_clear_style_sheets(struct webview *w) {
+  (void)w;
+  return -1;
+}
+
 WEBVIEW_API int webview_init(struct webview *w) {
   w->priv.pool = objc_msgSend((id)objc_getClass("NSAutoreleasePool"),
                               sel_registerName("new"));
@@ -1883,23 +2899,7 @@ WEBVIEW_API int webview_init(struct webview *w) {
       objc_msgSend((id)objc_getClass("NSString"),
                    sel_registerName("stringWithUTF8String:"), "invoke"));
 
-  /***
-   In order to maintain compatibility with the other 'webviews' we need to
-   override window.external.invoke to call
-   webkit.messageHandlers.invoke.postMessage
-   ***/
-
-  id windowExternalOverrideScript = objc_msgSend(
-      (id)objc_getClass("WKUserScript"), sel_registerName("alloc"));
-  objc_msgSend(
-      windowExternalOverrideScript,
-      sel_registerName("initWithSource:injectionTime:forMainFrameOnly:"),
-      get_nsstring("window.external = this; invoke = function(arg){ "
-                   "webkit.messageHandlers.invoke.postMessage(arg); };"),
-      WKUserScriptInjectionTimeAtDocumentStart, 0);
-
-  objc_msgSend(userController, sel_registerName("addUserScript:"),
-               windowExternalOverrideScript);
+  webview_add_external_script(userController);
 
   id config = objc_msgSend((id)objc_getClass("WKWebViewConfiguration"),
                            sel_registerName("new"));
@@ -1911,6 +2911,29 @@ WEBVIEW_API int webview_init(struct webview *w) {
                userController);
   objc_msgSend(config, sel_registerName("setPreferences:"), wkPref);
 
+  if (w->schemes != NULL && w->schemes[0] != NULL) {
+    Class __WKSchemeHandler = objc_allocateClassPair(
+        objc_getClass("NSObject"), "__WKSchemeHandler", 0);
+    class_addProtocol(__WKSchemeHandler,
+                      objc_getProtocol("WKURLSchemeHandler"));
+    class_addMethod(__WKSchemeHandler,
+                    sel_registerName("webView:startURLSchemeTask:"),
+                    (IMP)webview_start_scheme_task, "v@:@@");
+    class_addMethod(__WKSchemeHandler,
+                    sel_registerName("webView:stopURLSchemeTask:"),
+                    (IMP)webview_stop_scheme_task, "v@:@@");
+    objc_registerClassPair(__WKSchemeHandler);
+    id schemeHandler =
+        objc_msgSend((id)__WKSchemeHandler, sel_registerName("new"));
+    objc_setAssociatedObject(schemeHandler, "webview", (id)(w),
+                             OBJC_ASSOCIATION_ASSIGN);
+    const char **scheme;
+    for (scheme = w->schemes; *scheme != NULL; scheme++) {
+      objc_msgSend(config, sel_registerName("setURLSchemeHandler:forURLScheme:"),
+                   schemeHandler, get_nsstring(*scheme));
+    }
+  }
+
   Class __NSWindowDelegate = objc_allocateClassPair(objc_getClass("NSObject"),
                                                     "__NSWindowDelegate", 0);
   class_addProtocol(__NSWindowDelegate, objc_getProtocol("NSWindowDelegate"));
@@ -1976,8 +2999,39 @@ WEBVIEW_API int webview_init(struct webview *w) {
       sel_registerName(
           "webView:decidePolicyForNavigationResponse:decisionHandler:"),
       (IMP)make_nav_policy_decision, "v@:@@?");
+  class_addMethod(
+      __WKNavigationDelegate,
+      sel_registerName(
+          "webView:decidePolicyForNavigationAction:decisionHandler:"),
+      (IMP)webview_decide_policy_for_navigation_action, "v@:@@?");
+  class_addMethod(__WKNavigationDelegate,
+                  sel_registerName("webView:didFinishNavigation:"),
+                  (IMP)webview_did_finish_navigation, "v@:@@");
+  class_addMethod(__WKNavigationDelegate,
+                  sel_registerName("webView:didStartProvisionalNavigation:"),
+                  (IMP)webview_did_start_navigation, "v@:@@");
+  class_addMethod(__WKNavigationDelegate,
+                  sel_registerName("webView:"
+                                   "didReceiveServerRedirectForProvisionalNav"
+                                   "igation:"),
+                  (IMP)webview_did_redirect_navigation, "v@:@@");
+  class_addMethod(__WKNavigationDelegate,
+                  sel_registerName("webView:didCommitNavigation:"),
+                  (IMP)webview_did_commit_navigation, "v@:@@");
+  class_addMethod(
+      __WKNavigationDelegate,
+      sel_registerName("webView:didFailProvisionalNavigation:withError:"),
+      (IMP)webview_did_fail_navigation, "v@:@@@");
+  class_addMethod(__WKNavigationDelegate,
+                  sel_registerName("webView:didFailNavigation:withError:"),
+                  (IMP)webview_did_fail_navigation, "v@:@@@");
+  class_addMethod(__WKNavigationDelegate,
+                  sel_registerName("observeValueForKeyPath:ofObject:change:"
+                                   "context:"),
+                  (IMP)webview_observe_value, "v@:@@@^v");
   objc_registerClassPair(__WKNavigationDelegate);
   id navDel = objc_msgSend((id)__WKNavigationDelegate, sel_registerName("new"));
+  objc_setAssociatedObject(navDel, "webview", (id)(w), OBJC_ASSOCIATION_ASSIGN);
 
   w->priv.webview =
       objc_msgSend((id)objc_getClass("WKWebView"), sel_registerName("alloc"));
@@ -1986,6 +3040,9 @@ WEBVIEW_API int webview_init(struct webview *w) {
   objc_msgSend(w->priv.webview, sel_registerName("setUIDelegate:"), uiDel);
   objc_msgSend(w->priv.webview, sel_registerName("setNavigationDelegate:"),
                navDel);
+  objc_msgSend(w->priv.webview,
+               sel_registerName("addObserver:forKeyPath:options:context:"),
+               navDel, get_nsstring("estimatedProgress"), 0, NULL);
 
   id nsURL = objc_msgSend((id)objc_getClass("NSURL"),
                           sel_registerName("URLWithString:"),
@@ -2103,6 +3160,30 @@ WEBVIEW_API int webview_eval(struct webview *w, const char *js) {
   return 0;
 }
 
+WEBVIEW_API int webview_eval_result(struct webview *w, const char *js,
+                                    webview_eval_result_fn fn, void *arg) {
+  objc_msgSend(w->priv.webview,
+               sel_registerName("evaluateJavaScript:completionHandler:"),
+               get_nsstring(js), ^(id result, id error) {
+                 if (fn == NULL) {
+                   return;
+                 }
+                 if (error != nil) {
+                   fn(w, arg, NULL,
+                      (const char *)objc_msgSend(
+                          objc_msgSend(error,
+                                       sel_registerName("localizedDescription")),
+                          sel_registerName("UTF8String")));
+                 } else {
+                   fn(w, arg,
+                      (const char *)objc_msgSend(result,
+                                                 sel_registerName("UTF8String")),
+                      NULL);
+                 }
+               });
+  return 0;
+}
+
 WEBVIEW_API void webview_set_title(struct webview *w, const char *title) {
   objc_msgSend(w->priv.window, sel_registerName("setTitle"),
                get_nsstring(title));
@@ -2242,6 +3323,207 @@ WEBVIEW_API void webview_dispatch(struct webview *w, webview_dispatch_fn fn,
   dispatch_async_f(dispatch_get_main_queue(), context, webview_dispatch_cb);
 }
 
+WEBVIEW_API void webview_navigate(struct webview *w, const char *url) {
+  id nsURL = objc_msgSend((id)objc_getClass("NSURL"),
+                          sel_registerName("URLWithString:"),
+                          get_nsstring(webview_check_url(url)));
+  objc_msgSend(w->priv.webview, sel_registerName("loadRequest:"),
+               objc_msgSend((id)objc_getClass("NSURLRequest"),
+                            sel_registerName("requestWithURL:"), nsURL));
+}
+
+WEBVIEW_API int webview_set_html(struct webview *w, const char *html,
+                                 const char *base_url) {
+  id nsBaseURL = NULL;
+  if (base_url != NULL && *base_url) {
+    nsBaseURL = objc_msgSend((id)objc_getClass("NSURL"),
+                             sel_registerName("URLWithString:"),
+                             get_nsstring(base_url));
+  }
+  objc_msgSend(w->priv.webview, sel_registerName("loadHTMLString:baseURL:"),
+               get_nsstring(html), nsBaseURL);
+  return 0;
+}
+
+WEBVIEW_API void webview_reload(struct webview *w) {
+  objc_msgSend(w->priv.webview, sel_registerName("reload"));
+}
+
+WEBVIEW_API void webview_go_back(struct webview *w) {
+  objc_msgSend(w->priv.webview, sel_registerName("goBack"));
+}
+
+WEBVIEW_API void webview_go_forward(struct webview *w) {
+  objc_msgSend(w->priv.webview, sel_registerName("goForward"));
+}
+
+WEBVIEW_API void webview_stop(struct webview *w) {
+  objc_msgSend(w->priv.webview, sel_registerName("stopLoading"));
+}
+
+WEBVIEW_API void webview_get_url(struct webview *w, char *url, size_t urlsz) {
+  id nsURL = objc_msgSend(w->priv.webview, sel_registerName("URL"));
+  url[0] = '\0';
+  if (nsURL != NULL) {
+    const char *s = (const char *)objc_msgSend(
+        objc_msgSend(nsURL, sel_registerName("absoluteString")),
+        sel_registerName("UTF8String"));
+    strncpy(url, s, urlsz);
+    url[urlsz - 1] = '\0';
+  }
+}
+
+WEBVIEW_API const char *
+webview_scheme_task_url(struct webview_scheme_task *task) {
+  id url = objc_msgSend(objc_msgSend(task->task, sel_registerName("request")),
+                        sel_registerName("URL"));
+  return (const char *)objc_msgSend(
+      objc_msgSend(url, sel_registerName("absoluteString")),
+      sel_registerName("UTF8String"));
+}
+
+WEBVIEW_API const char *
+webview_scheme_task_method(struct webview_scheme_task *task) {
+  return (const char *)objc_msgSend(
+      objc_msgSend(objc_msgSend(task->task, sel_registerName("request")),
+                   sel_registerName("HTTPMethod")),
+      sel_registerName("UTF8String"));
+}
+
+WEBVIEW_API char *webview_scheme_task_headers(struct webview_scheme_task *task) {
+  id fields =
+      objc_msgSend(objc_msgSend(task->task, sel_registerName("request")),
+                   sel_registerName("allHTTPHeaderFields"));
+  id keys = objc_msgSend(fields, sel_registerName("keyEnumerator"));
+  size_t len = 0;
+  char *s = (char *)calloc(1, 1);
+  id key;
+  while ((key = objc_msgSend(keys, sel_registerName("nextObject"))) != NULL) {
+    const char *name =
+        (const char *)objc_msgSend(key, sel_registerName("UTF8String"));
+    const char *value = (const char *)objc_msgSend(
+        objc_msgSend(fields, sel_registerName("objectForKey:"), key),
+        sel_registerName("UTF8String"));
+    size_t n = strlen(name) + strlen(value) + 3;
+    s = (char *)realloc(s, len + n + 1);
+    snprintf(s + len, n + 1, "%s: %s\n", name, value);
+    len += n;
+  }
+  return s;
+}
+
+WEBVIEW_API int webview_scheme_task_read(struct webview_scheme_task *task,
+                                         char *buf, size_t sz) {
+  if (task->body == NULL) {
+    return 0;
+  }
+  size_t len =
+      (size_t)objc_msgSend(task->body, sel_registerName("length"));
+  if (task->offset + sz > len) {
+    sz = len - task->offset;
+  }
+  memcpy(buf,
+         (const char *)objc_msgSend(task->body, sel_registerName("bytes")) +
+             task->offset,
+         sz);
+  task->offset += sz;
+  return (int)sz;
+}
+
+static void webview_scheme_header(char *name, char *value, void *arg) {
+  objc_msgSend((id)arg, sel_registerName("setObject:forKey:"),
+               get_nsstring(value), get_nsstring(name));
+}
+
+static void webview_scheme_respond_cb(struct webview *w, void *arg) {
+  struct webview_scheme_task *t = (struct webview_scheme_task *)arg;
+  if (t->stopped) {
+    return;
+  }
+  id fields = objc_msgSend((id)objc_getClass("NSMutableDictionary"),
+                           sel_registerName("dictionary"));
+  webview_each_header(t->headers, webview_scheme_header, fields);
+  id url = objc_msgSend(objc_msgSend(t->task, sel_registerName("request")),
+                        sel_registerName("URL"));
+  id response = objc_msgSend((id)objc_getClass("NSHTTPURLResponse"),
+                             sel_registerName("alloc"));
+  response = objc_msgSend(
+      response,
+      sel_registerName("initWithURL:statusCode:HTTPVersion:headerFields:"),
+      url, (long)t->status, get_nsstring("HTTP/1.1"), fields);
+  objc_msgSend(t->task, sel_registerName("didReceiveResponse:"), response);
+  objc_msgSend(response, sel_registerName("release"));
+}
+
+WEBVIEW_API int webview_scheme_task_respond(struct webview_scheme_task *task,
+                                            int status, const char *headers) {
+  task->status = status;
+  task->headers = strdup(headers);
+  webview_dispatch(task->w, webview_scheme_respond_cb, task);
+  return 0;
+}
+
+struct webview_scheme_data {
+  struct webview_scheme_task *task;
+  id data;
+};
+
+static void webview_scheme_write_cb(struct webview *w, void *arg) {
+  struct webview_scheme_data *d = (struct webview_scheme_data *)arg;
+  if (!d->task->stopped) {
+    objc_msgSend(d->task->task, sel_registerName("didReceiveData:"), d->data);
+  }
+  objc_msgSend(d->data, sel_registerName("release"));
+  free(d);
+}
+
+WEBVIEW_API int webview_scheme_task_write(struct webview_scheme_task *task,
+                                          const char *buf, size_t sz) {
+  if (task->stopped) {
+    return -1;
+  }
+  struct webview_scheme_data *d =
+      (struct webview_scheme_data *)malloc(sizeof(*d));
+  d->task = task;
+  d->data = objc_msgSend(
+      objc_msgSend((id)objc_getClass("NSData"), sel_registerName("alloc")),
+      sel_registerName("initWithBytes:length:"), buf, (unsigned long)sz);
+  webview_dispatch(task->w, webview_scheme_write_cb, d);
+  return 0;
+}
+
+static void webview_scheme_finish_cb(struct webview *w, void *arg) {
+  struct webview_scheme_task *t = (struct webview_scheme_task *)arg;
+  if (!t->stopped && t->status == 0) {
+    id info = objc_msgSend(
+        (id)objc_getClass("NSDictionary"),
+        sel_registerName("dictionaryWithObject:forKey:"),
+        get_nsstring(t->err != NULL ? t->err : "no response"),
+        get_nsstring("NSLocalizedDescription"));
+    id err = objc_msgSend((id)objc_getClass("NSError"),
+                          sel_registerName("errorWithDomain:code:userInfo:"),
+                          get_nsstring("webview"), (long)-1, info);
+    objc_msgSend(t->task, sel_registerName("didFailWithError:"), err);
+  } else if (!t->stopped) {
+    objc_msgSend(t->task, sel_registerName("didFinish"));
+  }
+  objc_setAssociatedObject(t->task, "webview_scheme_task", NULL,
+                           OBJC_ASSOCIATION_ASSIGN);
+  objc_msgSend(t->task, sel_registerName("release"));
+  if (t->body != NULL) {
+    objc_msgSend(t->body, sel_registerName("release"));
+  }
+  free(t->headers);
+  free(t->err);
+  free(t);
+}
+
+WEBVIEW_API void webview_scheme_task_finish(struct webview_scheme_task *task,
+                                            const char *err) {
+  task->err = (err != NULL ? strdup(err) : NULL);
+  webview_dispatch(task->w, webview_scheme_finish_cb, task);
+}
+
 WEBVIEW_API void webview_terminate(struct webview *w) {
   w->priv.should_exit = 1;
 }
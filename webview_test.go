@@ -1,8 +1,24 @@
 package webview
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"image"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
+	"unsafe"
 )
 
 type foo struct {
@@ -19,6 +35,143 @@ func (f *foo) Foo3(a []image.Point, b struct{ Z int }) {
 	f.Result = map[string]interface{}{"a": a, "b": b}
 }
 
+func (f *foo) Sum(a, b int) int {
+	return a + b
+}
+func (f *foo) Pair(s string) (string, int) {
+	return s, len(s)
+}
+
+func (f *foo) Div(a, b int) (int, error) {
+	if b == 0 {
+		return 0, &Error{Name: "RangeError", Message: "division by zero", Code: "EDIVZERO"}
+	}
+	return a / b, nil
+}
+
+type codedError struct{}
+
+func (codedError) Error() string     { return "coded" }
+func (codedError) Code() string      { return "ECODED" }
+func (codedError) Data() interface{} { return []int{1, 2} }
+
+func (f *foo) Fail() error {
+	return fmt.Errorf("wrapped: %w", codedError{})
+}
+
+func (f *foo) Panic() int {
+	panic("oops")
+}
+
+type waiter struct {
+	started chan struct{}
+}
+
+func (w *waiter) Wait(ctx context.Context, label string) (string, error) {
+	if w.started != nil {
+		close(w.started)
+	}
+	<-ctx.Done()
+	return label, ctx.Err()
+}
+
+func (w *waiter) Now(ctx context.Context) bool {
+	return ctx.Err() == nil
+}
+
+func TestBindingContext(t *testing.T) {
+	var reply rpcReply
+	wt := &waiter{}
+	b, err := newBinding("test", wt, BindTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.reply = func(r rpcReply) { reply = r }
+	for _, mi := range b.Methods {
+		if !mi.Context() || mi.Arity() != mi.Value.Type().NumIn()-1 {
+			t.Fatal(mi.Name, mi.Arity())
+		}
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		if !b.Call(`{"id":1,"scope":"test","method":"Wait","params":["x"]}`) {
+			t.Fatal()
+		}
+		if reply.Err != context.DeadlineExceeded {
+			t.Fatal(reply)
+		}
+		if !b.Call(`{"id":2,"scope":"test","method":"Now","params":[]}`) {
+			t.Fatal()
+		}
+		if reply.Err != nil || reply.Result != true {
+			t.Fatal(reply)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		b.timeout = 0
+		wt.started = make(chan struct{})
+		done := make(chan rpcReply)
+		b.reply = func(r rpcReply) { done <- r }
+		b.exec = (&workerPool{size: 1}).Submit
+		defer func() { b.exec = nil }()
+		if !b.Call(`{"id":3,"scope":"test","method":"Wait","params":["x"]}`) {
+			t.Fatal()
+		}
+		<-wt.started
+		if !b.Call(`{"id":3,"scope":"test","cancel":true}`) {
+			t.Fatal()
+		}
+		if r := <-done; r.ID != 3 || r.Err != context.Canceled {
+			t.Fatal(r)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		done := make(chan rpcReply)
+		b.reply = func(r rpcReply) { done <- r }
+		b.exec = (&workerPool{size: 2}).Submit
+		defer func() { b.exec = nil }()
+		wt.started = make(chan struct{})
+		if !b.Call(`{"id":5,"scope":"test","method":"Wait","params":["old"]}`) {
+			t.Fatal()
+		}
+		<-wt.started
+		// A new page is loaded, the call of the previous page is cancelled
+		// and its reply is dropped
+		b.reset()
+		r := <-done
+		if r.ID != 5 || r.Err != context.Canceled || b.current(r.Page) {
+			t.Fatal(r)
+		}
+		// The IDs restart on the new page
+		wt.started = make(chan struct{})
+		if !b.Call(`{"id":5,"scope":"test","method":"Wait","params":["new"]}`) {
+			t.Fatal()
+		}
+		<-wt.started
+		if !b.Call(`{"id":5,"scope":"test","cancel":true}`) {
+			t.Fatal()
+		}
+		if r := <-done; r.ID != 5 || r.Err != context.Canceled || !b.current(r.Page) {
+			t.Fatal(r)
+		}
+	})
+
+	t.Run("Parent", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		b.ctx = ctx
+		b.reply = func(r rpcReply) { reply = r }
+		if !b.Call(`{"id":4,"scope":"test","method":"Now","params":[]}`) {
+			t.Fatal()
+		}
+		if reply.Result != false {
+			t.Fatal(reply)
+		}
+	})
+}
+
 func TestBadBinding(t *testing.T) {
 	x := 123
 	for _, v := range []interface{}{
@@ -41,6 +194,147 @@ func TestBadBinding(t *testing.T) {
 	}
 }
 
+func TestBadFuncBinding(t *testing.T) {
+	var nilFunc func()
+	for _, v := range []interface{}{
+		nil,
+		123,
+		"hello",
+		&foo{},
+		foo{},
+		nilFunc,
+	} {
+		if _, err := newFuncBinding("test", v); err == nil {
+			t.Errorf("should return an error: %#v", v)
+		}
+	}
+}
+
+func TestFuncBindingCall(t *testing.T) {
+	var reply rpcReply
+	b, err := newFuncBinding("hash", func(s string, n int) (string, error) {
+		if n < 0 {
+			return "", errors.New("negative")
+		}
+		return strings.Repeat(s, n), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.reply = func(r rpcReply) { reply = r }
+	if js, err := b.JS(); err != nil || !strings.Contains(js, `window.webview._ns("")["hash"] = function(a0,a1) {`) {
+		t.Fatal(js, err)
+	}
+	if js, err := b.Sync(); err != nil || js != "" {
+		t.Fatal(js, err)
+	}
+	if !b.Call(`{"id":1,"scope":"hash","method":"","params":["ab",2]}`) {
+		t.Fatal()
+	}
+	if reply.Err != nil || reply.Result != "abab" {
+		t.Fatal(reply)
+	}
+	if !b.Call(`{"id":2,"scope":"hash","method":"","params":["ab",-1]}`) {
+		t.Fatal()
+	}
+	if reply.Err == nil || reply.Err.Error() != "negative" {
+		t.Fatal(reply)
+	}
+	if !b.Call(`{"id":3,"scope":"hash","method":"","params":[1,2]}`) {
+		t.Fatal()
+	}
+	if argErr, ok := reply.Err.(*ArgumentError); !ok || argErr.Method != "hash" {
+		t.Fatal(reply)
+	}
+	if b.Call(`{"id":4,"scope":"hash","method":"Foo","params":[]}`) {
+		t.Fatal()
+	}
+}
+
+type files struct {
+	Opened string
+}
+
+func (f *files) Open(path string) string {
+	f.Opened = path
+	return path
+}
+
+type settings struct {
+	Values map[string]string
+}
+
+func (s settings) Get(key string) string {
+	return s.Values[key]
+}
+
+type app struct {
+	Files    files     `webview:"files"`
+	Settings *settings `webview:"settings"`
+	Hidden   *settings
+	Self     *app `webview:"self"`
+}
+
+func (a *app) Version() string { return "1.0" }
+
+func TestNestedBinding(t *testing.T) {
+	var reply rpcReply
+	a := &app{Settings: &settings{Values: map[string]string{"theme": "dark"}}}
+	a.Self = a
+	b, err := newBinding("my.app", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.reply = func(r rpcReply) { reply = r }
+	scopes := map[string]string{}
+	for _, mi := range b.Methods {
+		scopes[mi.Scope+"."+mi.Name] = mi.JSName()
+	}
+	if len(scopes) != 3 || scopes["my.app.Version"] != "version" ||
+		scopes["my.app.files.Open"] != "open" || scopes["my.app.settings.Get"] != "get" {
+		t.Fatal(scopes)
+	}
+	js, err := b.JS()
+	if err != nil || !strings.Contains(js, `window.webview._ns("my.app.files").open = function(a0) {`) {
+		t.Fatal(js, err)
+	}
+
+	if !b.Call(`{"id":1,"scope":"my.app.files","method":"Open","params":["/tmp"]}`) {
+		t.Fatal()
+	}
+	if reply.Result != "/tmp" || a.Files.Opened != "/tmp" {
+		t.Fatal(reply, a.Files)
+	}
+	if !b.Call(`{"id":2,"scope":"my.app.settings","method":"Get","params":["theme"]}`) {
+		t.Fatal()
+	}
+	if reply.Result != "dark" {
+		t.Fatal(reply)
+	}
+	if !b.Call(`{"id":3,"scope":"my.app","method":"Version","params":[]}`) {
+		t.Fatal()
+	}
+	if reply.Result != "1.0" {
+		t.Fatal(reply)
+	}
+	for _, msg := range []string{
+		`{"id":4,"scope":"my.app","method":"Open","params":["/"]}`,
+		`{"id":5,"scope":"my.app.files","method":"Version","params":[]}`,
+		`{"id":6,"scope":"my.application","method":"Version","params":[]}`,
+		`{"id":7,"scope":"my","method":"Version","params":[]}`,
+	} {
+		if b.Call(msg) {
+			t.Fatal(msg)
+		}
+	}
+
+	for _, name := range []string{"", "a..b", "1a", "a-b", ".a", "a."} {
+		if _, err := newBinding(name, a); err == nil {
+			t.Errorf("should return an error: %q", name)
+		}
+	}
+}
+
 func TestBindingCall(t *testing.T) {
 	foo := &foo{}
 	b, err := newBinding("test", foo)
@@ -86,12 +380,1188 @@ func TestBindingCall(t *testing.T) {
 		}
 	})
 
+	t.Run("Results", func(t *testing.T) {
+		var reply rpcReply
+		b.reply = func(r rpcReply) { reply = r }
+		defer func() { b.reply = nil }()
+
+		if !b.Call(`{"id":1,"scope":"test","method":"Sum","params":[3,4]}`) {
+			t.Fatal()
+		}
+		if reply.ID != 1 || reply.Err != nil || reply.Result.(int) != 7 {
+			t.Fatal(reply)
+		}
+		if js, err := reply.JS(); err != nil || js != "window.webview._resolve(1,7);" {
+			t.Fatal(js, err)
+		}
+
+		if !b.Call(`{"id":2,"scope":"test","method":"Pair","params":["hello"]}`) {
+			t.Fatal()
+		}
+		if js, err := reply.JS(); err != nil || js != `window.webview._resolve(2,["hello",5]);` {
+			t.Fatal(js, err)
+		}
+
+		if !b.Call(`{"id":3,"scope":"test","method":"Foo1","params":[1,2]}`) {
+			t.Fatal()
+		}
+		if js, err := reply.JS(); err != nil || js != "window.webview._resolve(3,null);" {
+			t.Fatal(js, err)
+		}
+	})
+
+	t.Run("ErrorResults", func(t *testing.T) {
+		var reply rpcReply
+		b.reply = func(r rpcReply) { reply = r }
+		defer func() { b.reply = nil }()
+
+		if !b.Call(`{"id":1,"scope":"test","method":"Div","params":[6,3]}`) {
+			t.Fatal()
+		}
+		if js, err := reply.JS(); err != nil || js != "window.webview._resolve(1,2);" {
+			t.Fatal(js, err)
+		}
+
+		if !b.Call(`{"id":2,"scope":"test","method":"Div","params":[6,0]}`) {
+			t.Fatal()
+		}
+		if js, err := reply.JS(); err != nil ||
+			js != `window.webview._reject(2,{"name":"RangeError","message":"division by zero","code":"EDIVZERO"});` {
+			t.Fatal(js, err)
+		}
+
+		if !b.Call(`{"id":3,"scope":"test","method":"Fail","params":[]}`) {
+			t.Fatal()
+		}
+		if js, err := reply.JS(); err != nil ||
+			js != `window.webview._reject(3,{"name":"Error","message":"wrapped: coded","code":"ECODED","data":[1,2]});` {
+			t.Fatal(js, err)
+		}
+	})
+
+	t.Run("Panics", func(t *testing.T) {
+		var reply rpcReply
+		b.reply = func(r rpcReply) { reply = r }
+		defer func() { b.reply = nil }()
+
+		if !b.Call(`{"id":1,"scope":"test","method":"Panic","params":[]}`) {
+			t.Fatal()
+		}
+		panicErr, ok := reply.Err.(*PanicError)
+		if !ok || panicErr.Where != "test.Panic" || panicErr.Value != "oops" || len(panicErr.Stack) == 0 {
+			t.Fatal(reply.Err)
+		}
+		if js, err := reply.JS(); err != nil ||
+			js != `window.webview._reject(1,{"name":"Error","message":"webview: panic in test.Panic: oops"});` {
+			t.Fatal(js, err)
+		}
+	})
+
 	t.Run("Errors", func(t *testing.T) {
 		if b.Call(`{"scope":"foo"}`) || b.Call(`{"scope":"test", "method":"Bar"}`) {
 			t.Fatal()
 		}
-		if b.Call(`{"scope":"test","method":"Foo1","params":["3",4.5]}`) {
+	})
+
+	t.Run("ArgumentErrors", func(t *testing.T) {
+		var reply rpcReply
+		b.reply = func(r rpcReply) { reply = r }
+		defer func() { b.reply = nil }()
+
+		foo.Result = nil
+		if !b.Call(`{"id":1,"scope":"test","method":"Foo1","params":["3",4.5]}`) {
+			t.Fatal()
+		}
+		argErr, ok := reply.Err.(*ArgumentError)
+		if !ok || argErr.Method != "test.Foo1" || argErr.Index != 0 ||
+			argErr.Type != reflect.TypeOf(0) || argErr.Path != "params[0]" || foo.Result != nil {
+			t.Fatal(reply.Err)
+		}
+		if js, err := reply.JS(); err != nil || !strings.HasPrefix(js, `window.webview._reject(1,{"name":"TypeError",`) {
+			t.Fatal(js, err)
+		}
+
+		if !b.Call(`{"id":2,"scope":"test","method":"Foo3","params":[[{"X":1,"Y":2},{"X":"3","Y":4}],{"Z":42}]}`) {
 			t.Fatal()
 		}
+		if argErr, ok := reply.Err.(*ArgumentError); !ok || argErr.Index != 0 ||
+			!strings.HasPrefix(argErr.Path, "params[0]") || !strings.HasSuffix(argErr.Path, ".X") {
+			t.Fatal(reply.Err)
+		}
+
+		for _, params := range []string{`[]`, `[3]`, `[3,4.5,6]`} {
+			if !b.Call(`{"id":3,"scope":"test","method":"Foo1","params":` + params + `}`) {
+				t.Fatal(params)
+			}
+			if argErr, ok := reply.Err.(*ArgumentError); !ok || argErr.Index != -1 || foo.Result != nil {
+				t.Fatal(params, reply.Err)
+			}
+		}
+	})
+}
+
+type tally struct {
+	Count int `json:"count"`
+}
+
+func (t *tally) Add(n int) {
+	t.Count += n
+}
+
+// fragile panics when it is encoded once Broken is set
+type fragile struct {
+	Broken bool
+}
+
+func (f *fragile) MarshalJSON() ([]byte, error) {
+	if f.Broken {
+		panic("broken")
+	}
+	return []byte(`{}`), nil
+}
+
+func (f *fragile) Break() {
+	f.Broken = true
+}
+
+func TestBindSyncPanic(t *testing.T) {
+	b, err := newBinding("fragile", &fragile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply *rpcReply
+	b.reply = func(r rpcReply) { reply = &r }
+	if !b.Call(`{"id":1,"scope":"fragile","method":"Break","params":[]}`) {
+		t.Fatal()
+	}
+	var panicErr *PanicError
+	if reply == nil || reply.ID != 1 || !errors.As(reply.Err, &panicErr) || panicErr.Where != "fragile.Break" || panicErr.Value != "broken" {
+		t.Fatal(reply)
+	}
+	if len(b.calls) != 0 {
+		t.Fatal(b.calls)
+	}
+}
+
+// TestBindSerialPanic checks that a panic on a worker goroutine rejects the
+// call instead of crashing, and that the worker keeps running the calls.
+func TestBindSerialPanic(t *testing.T) {
+	b, err := newBinding("fragile", &fragile{}, BindSerial())
+	if err != nil {
+		t.Fatal(err)
+	}
+	replies := make(chan rpcReply, 2)
+	b.reply = func(r rpcReply) { replies <- r }
+	b.exec = (&workerPool{size: 1}).Submit
+	for i := 1; i <= 2; i++ {
+		if !b.Call(fmt.Sprintf(`{"id":%d,"scope":"fragile","method":"Break","params":[]}`, i)) {
+			t.Fatal(i)
+		}
+	}
+	for i := 1; i <= 2; i++ {
+		var panicErr *PanicError
+		if r := <-replies; r.ID != i || !errors.As(r.Err, &panicErr) {
+			t.Fatal(r)
+		}
+	}
+}
+
+// TestBindSerialSync must pass with -race: the value is changed by the worker
+// goroutine while the replies are handled by the main thread.
+func TestBindSerialSync(t *testing.T) {
+	b, err := newBinding("tally", &tally{}, BindSerial())
+	if err != nil {
+		t.Fatal(err)
+	}
+	replies := make(chan rpcReply, 100)
+	b.reply = func(r rpcReply) { replies <- r }
+	b.exec = (&workerPool{size: 1}).Submit
+	for i := 1; i <= 100; i++ {
+		if !b.Call(fmt.Sprintf(`{"id":%d,"scope":"tally","method":"Add","params":[1]}`, i)) {
+			t.Fatal(i)
+		}
+	}
+	for i := 1; i <= 100; i++ {
+		r := <-replies
+		sync := fmt.Sprintf(`tally.data={"count":%d};if(tally.render){tally.render({"count":%[1]d});}`, i)
+		if r.ID != i || r.Sync != sync {
+			t.Fatal(r)
+		}
+	}
+}
+
+func TestWorkerPool(t *testing.T) {
+	pool := &workerPool{size: 2}
+	serial := &workerPool{size: 1, parent: pool}
+
+	var (
+		mu             sync.Mutex
+		wg             sync.WaitGroup
+		running, max   int
+		serialRunning  int
+		order          []int
+		serialOverlaps bool
+	)
+	track := func(delta int) {
+		mu.Lock()
+		running += delta
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+	}
+	for i := 0; i < 20; i++ {
+		i := i
+		wg.Add(2)
+		pool.Submit(func() {
+			defer wg.Done()
+			track(1)
+			time.Sleep(time.Millisecond)
+			track(-1)
+		})
+		serial.Submit(func() {
+			defer wg.Done()
+			track(1)
+			mu.Lock()
+			serialRunning++
+			if serialRunning > 1 {
+				serialOverlaps = true
+			}
+			order = append(order, i)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			serialRunning--
+			mu.Unlock()
+			track(-1)
+		})
+	}
+	wg.Wait()
+	if max > 2 {
+		t.Fatal("too many concurrent workers", max)
+	}
+	if serialOverlaps {
+		t.Fatal("serial calls overlap")
+	}
+	for i, n := range order {
+		if i != n {
+			t.Fatal("serial calls out of order", order)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := &registry{}
+	f := &foo{}
+	b1, _ := newBinding("test", f)
+	b2, _ := newBinding("my.app", &app{})
+	b3, _ := newFuncBinding("my.app.hash", func(s string) string { return s })
+	for _, b := range []*binding{b1, b2, b3} {
+		if old := r.Add(b); old != nil {
+			t.Fatal(old)
+		}
+	}
+
+	var reply rpcReply
+	b3.reply = func(rr rpcReply) { reply = rr }
+	for msg, ok := range map[string]bool{
+		`{"id":1,"scope":"test","method":"Foo1","params":[1,2]}`:         true,
+		`{"id":2,"scope":"my.app.files","method":"Open","params":["/"]}`: true,
+		`{"id":3,"scope":"my.app.hash","method":"","params":["x"]}`:      true,
+		`{"id":4,"scope":"my.app.hash.x","method":"","params":["x"]}`:    false,
+		`{"id":5,"scope":"my","method":"Version","params":[]}`:           false,
+		`{"id":6,"scope":"testing","method":"Foo1","params":[1,2]}`:      false,
+		`{"id":7,"scope":"","method":"Foo1","params":[1,2]}`:             false,
+		`hello`: false,
+	} {
+		if r.Call(msg) != ok {
+			t.Error(msg)
+		}
+	}
+	if f.Result.(float64) != 3 || reply.Result != "x" {
+		t.Fatal(f.Result, reply)
+	}
+
+	info := r.Info()
+	if len(info) != 3 || info[0].Name != "my.app" || info[1].Name != "my.app.hash" || info[2].Name != "test" {
+		t.Fatal(info)
+	}
+	if !info[1].Func || len(info[1].Methods) != 1 || info[1].Methods[0].Arity != 1 {
+		t.Fatal(info[1])
+	}
+
+	js := r.JS()
+	if i, j := strings.Index(js, `window.webview._ns("my.app");`), strings.Index(js, `window.webview._ns("my.app")["hash"]`); i < 0 || j < i ||
+		!strings.Contains(js, `window.webview._ns("test");`) {
+		t.Fatal(js)
+	}
+
+	f2 := &foo{}
+	b4, _ := newBinding("test", f2)
+	if old := r.Add(b4); old != b1 {
+		t.Fatal(old)
+	}
+	if !r.Call(`{"id":8,"scope":"test","method":"Foo1","params":[2,2]}`) || f2.Result.(float64) != 4 || f.Result.(float64) != 3 {
+		t.Fatal(f.Result, f2.Result)
+	}
+	if r.Remove("test") != b4 || r.Remove("test") != nil {
+		t.Fatal()
+	}
+	if r.Call(`{"id":9,"scope":"test","method":"Foo1","params":[2,2]}`) || len(r.Info()) != 2 {
+		t.Fatal()
+	}
+}
+
+type service struct {
+	Files files `webview:"files"`
+}
+
+func (s *service) HTTPGet(url string) string { return url }
+func (s *service) ID() int                   { return 1 }
+func (s *service) Close()                    {}
+func (s *service) Start()                    {}
+func (s *service) Stop()                     {}
+
+type listedService struct{ service }
+
+func (s *listedService) WebViewMethods() []string { return []string{"HTTPGet", "Close"} }
+
+type namedService struct{ service }
+
+func (s *namedService) JSName(method string) string {
+	switch method {
+	case "HTTPGet":
+		return "fetch"
+	case "Close":
+		return "-"
+	}
+	return ""
+}
+
+func TestMethodExposure(t *testing.T) {
+	names := func(b *binding) string {
+		var names []string
+		for _, mi := range b.Methods {
+			names = append(names, strings.TrimPrefix(mi.Scope+"."+mi.JSName(), "test."))
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+	for _, test := range []struct {
+		V     interface{}
+		Opts  []BindOption
+		Names string
+	}{
+		{&service{}, nil, "close,files.open,httpGet,id,start,stop"},
+		{&listedService{}, nil, "close,files.open,httpGet"},
+		{&namedService{}, nil, "fetch,files.open,id,start,stop"},
+		{&service{}, []BindOption{BindExclude("Close", "files.Open")}, "httpGet,id,start,stop"},
+		{&service{}, []BindOption{BindExclude("Open")}, "close,httpGet,id,start,stop"},
+		{&service{}, []BindOption{BindNameFunc(strings.ToLower)}, "close,files.open,httpget,id,start,stop"},
+		{&namedService{}, []BindOption{BindNameFunc(strings.ToUpper)}, "ID,START,STOP,fetch,files.OPEN"},
+	} {
+		b, err := newBinding("test", test.V, test.Opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := names(b); n != test.Names {
+			t.Errorf("%T: %s != %s", test.V, n, test.Names)
+		}
+	}
+
+	if _, err := newBinding("test", &service{}, BindNameFunc(func(string) string { return "a.b" })); err == nil {
+		t.Fatal("invalid name should return an error")
+	}
+}
+
+func TestJSName(t *testing.T) {
+	for name, js := range map[string]string{
+		"Foo":     "foo",
+		"FooBar":  "fooBar",
+		"HTTPGet": "httpGet",
+		"ID":      "id",
+		"GetID":   "getID",
+		"A":       "a",
+		"AB":      "ab",
+		"ABc":     "aBc",
+	} {
+		if s := (methodInfo{Name: name}).JSName(); s != js {
+			t.Errorf("%s: %s != %s", name, s, js)
+		}
+	}
+}
+
+type logger struct {
+	Lines []string
+}
+
+func (l *logger) Log(prefix string, parts ...string) int {
+	l.Lines = append(l.Lines, prefix+strings.Join(parts, " "))
+	return len(parts)
+}
+
+func (l *logger) Open(path string, mode *int, flags *[]string) string {
+	s := path
+	if mode != nil {
+		s = s + fmt.Sprintf(" %o", *mode)
+	}
+	if flags != nil {
+		s = s + " " + strings.Join(*flags, "|")
+	}
+	return s
+}
+
+func (l *logger) Count(ctx context.Context, n ...int) int {
+	return len(n)
+}
+
+func TestOptionalArguments(t *testing.T) {
+	var reply rpcReply
+	b, err := newBinding("test", &logger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.reply = func(r rpcReply) { reply = r }
+	arity := map[string][3]interface{}{}
+	for _, mi := range b.Methods {
+		arity[mi.Name] = [3]interface{}{mi.MinArity(), mi.Arity(), mi.Variadic()}
+	}
+	if arity["Log"] != [3]interface{}{1, 1, true} || arity["Open"] != [3]interface{}{1, 3, false} ||
+		arity["Count"] != [3]interface{}{0, 0, true} {
+		t.Fatal(arity)
+	}
+
+	for msg, result := range map[string]interface{}{
+		`{"id":1,"scope":"test","method":"Log","params":[">"]}`:                    0,
+		`{"id":1,"scope":"test","method":"Log","params":[">","a","b"]}`:            2,
+		`{"id":1,"scope":"test","method":"Open","params":["/tmp"]}`:                "/tmp",
+		`{"id":1,"scope":"test","method":"Open","params":["/tmp",420]}`:            "/tmp 644",
+		`{"id":1,"scope":"test","method":"Open","params":["/tmp",null,["a","b"]]}`: "/tmp a|b",
+		`{"id":1,"scope":"test","method":"Count","params":[]}`:                     0,
+		`{"id":1,"scope":"test","method":"Count","params":[1,2,3]}`:                3,
+	} {
+		if !b.Call(msg) {
+			t.Fatal(msg)
+		}
+		if reply.Err != nil || reply.Result != result {
+			t.Fatal(msg, reply)
+		}
+	}
+
+	for msg, index := range map[string]int{
+		`{"id":1,"scope":"test","method":"Log","params":[]}`:                 -1,
+		`{"id":1,"scope":"test","method":"Log","params":[">","a",1]}`:        2,
+		`{"id":1,"scope":"test","method":"Open","params":[]}`:                -1,
+		`{"id":1,"scope":"test","method":"Open","params":["/tmp",1,null,2]}`: -1,
+		`{"id":1,"scope":"test","method":"Count","params":["x"]}`:            0,
+	} {
+		if !b.Call(msg) {
+			t.Fatal(msg)
+		}
+		if argErr, ok := reply.Err.(*ArgumentError); !ok || argErr.Index != index {
+			t.Fatal(msg, reply.Err)
+		}
+	}
+
+	js, err := b.JS()
+	if err != nil || !strings.Contains(js, `.log = function(a0) {`) || !strings.Contains(js, `.count = function() {`) {
+		t.Fatal(js, err)
+	}
+}
+
+type watcher struct {
+	fn       JSFunc
+	optional *JSFunc
+}
+
+func (w *watcher) Watch(path string, fn JSFunc, optional *JSFunc) {
+	w.fn, w.optional = fn, optional
+}
+
+func TestJSFunc(t *testing.T) {
+	var evals []string
+	wt := &watcher{}
+	b, err := newBinding("test", wt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.eval = func(page int, js string) {
+		if b.current(page) {
+			evals = append(evals, js)
+		}
+	}
+
+	if !b.Call(`{"id":1,"scope":"test","method":"Watch","params":["/tmp",{"$fn":3}]}`) {
+		t.Fatal()
+	}
+	if wt.optional != nil {
+		t.Fatal(wt.optional)
+	}
+	if err := wt.fn.Call("changed", map[string]int{"size": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.fn.Call(); err != nil {
+		t.Fatal(err)
+	}
+	wt.fn.Release()
+
+	if !b.Call(`{"id":2,"scope":"test","method":"Watch","params":["/tmp",{"$fn":4},{"$fn":5}]}`) {
+		t.Fatal()
+	}
+	if err := wt.optional.Call(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := strings.Join(evals, ""); s != `window.webview._callback(3,["changed",{"size":1}]);`+
+		`window.webview._callback(3,[]);`+
+		`window.webview._release(3);`+
+		`window.webview._callback(5,[1]);` {
+		t.Fatal(s)
+	}
+
+	var reply rpcReply
+	b.reply = func(r rpcReply) { reply = r }
+	if !b.Call(`{"id":3,"scope":"test","method":"Watch","params":["/tmp",42]}`) {
+		t.Fatal()
+	}
+	if argErr, ok := reply.Err.(*ArgumentError); !ok || argErr.Index != 1 {
+		t.Fatal(reply.Err)
+	}
+
+	if err := (JSFunc{}).Call(); err == nil {
+		t.Fatal("unbound JSFunc should return an error")
+	}
+
+	// The functions of an unloaded page can't be called or released, the
+	// same ID refers to another function on the new page
+	fn := *wt.optional
+	b.reset()
+	evals = nil
+	if err := fn.Call(1); err == nil {
+		t.Fatal("stale JSFunc should return an error")
+	}
+	fn.Release()
+	if len(evals) != 0 {
+		t.Fatal(evals)
+	}
+	if !b.Call(`{"id":1,"scope":"test","method":"Watch","params":["/tmp",{"$fn":5}]}`) {
+		t.Fatal()
+	}
+	if err := wt.fn.Call(); err != nil || len(evals) != 1 {
+		t.Fatal(evals, err)
+	}
+}
+
+type tailer struct {
+	stopped chan struct{}
+}
+
+func (t *tailer) Tail(ctx context.Context, lines ...string) <-chan string {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		defer close(t.stopped)
+		for _, line := range lines {
+			select {
+			case ch <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func (t *tailer) None() <-chan int {
+	return nil
+}
+
+// runJS runs the JavaScript runtime and the script with node, which plays the
+// part of the webview, and returns what the script prints. The test is
+// skipped if node is not installed.
+func runJS(t *testing.T, js string) string {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	prelude := `
+		var window = global, messages = [];
+		window.external = {invoke: function(msg) { messages.push(JSON.parse(msg)); }};
+		window.document = {readyState: 'complete', getElementById: function() { return null; }};
+	`
+	out, err := exec.Command(node, "-e", prelude+runtimeJS+js).CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(out))
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestStreamIterable(t *testing.T) {
+	b, err := newBinding("test", &tailer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stubs, err := b.JS()
+	if err != nil || !strings.Contains(stubs, `"Tail", Array.prototype.slice.call(arguments), true);`) {
+		t.Fatal(stubs, err)
+	}
+	// Go replies to the call with a stream, and to every "next" with a line
+	out := runJS(t, stubs+`
+		var lines = ['a', 'b'];
+		window.external.invoke = function(msg) {
+			msg = JSON.parse(msg);
+			setTimeout(function() {
+				if (msg.method === 'Tail') {
+					webview._resolve(msg.id, {$stream: 1, scope: 'test'});
+				} else if (msg.op === 'next') {
+					webview._resolve(msg.id, lines.length ? {value: lines.shift(), done: false} : {done: true});
+				}
+			}, 0);
+		};
+		(async function() {
+			var got = [];
+			for await (const line of test.tail()) {
+				got.push(line);
+			}
+			lines = ['c'];
+			for await (const line of await test.tail()) {
+				got.push(line);
+			}
+			console.log(got.join(','));
+		})();
+	`)
+	if out != "a,b,c" {
+		t.Fatal(out)
+	}
+}
+
+func TestStream(t *testing.T) {
+	replies := make(chan rpcReply, 1)
+	tl := &tailer{}
+	b, err := newBinding("test", tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.reply = func(r rpcReply) { replies <- r }
+	call := func(msg string) rpcReply {
+		if !b.Call(msg) {
+			t.Fatal(msg)
+		}
+		return <-replies
+	}
+
+	tl.stopped = make(chan struct{})
+	r := call(`{"id":1,"scope":"test","method":"Tail","params":["a","b"]}`)
+	ref, ok := r.Result.(streamRef)
+	if !ok || ref.Scope != "test" {
+		t.Fatal(r)
+	}
+	if js, err := r.JS(); err != nil || js != fmt.Sprintf(`window.webview._resolve(1,{"$stream":%d,"scope":"test"});`, ref.ID) {
+		t.Fatal(js, err)
+	}
+	for i, want := range []string{`{"value":"a","done":false}`, `{"value":"b","done":false}`, `{"done":true}`, `{"done":true}`} {
+		r := call(fmt.Sprintf(`{"id":%d,"scope":"test","stream":%d,"op":"next"}`, i+2, ref.ID))
+		if js, err := r.JS(); err != nil || js != fmt.Sprintf("window.webview._resolve(%d,%s);", i+2, want) {
+			t.Fatal(js, err)
+		}
+	}
+	<-tl.stopped
+
+	// Closing the stream cancels the context of the method
+	tl.stopped = make(chan struct{})
+	ref = call(`{"id":1,"scope":"test","method":"Tail","params":["a","b","c"]}`).Result.(streamRef)
+	if r := call(fmt.Sprintf(`{"id":2,"scope":"test","stream":%d,"op":"next"}`, ref.ID)); r.Result.(streamValue).Value != "a" {
+		t.Fatal(r)
+	}
+	if r := call(fmt.Sprintf(`{"id":3,"scope":"test","stream":%d,"op":"close"}`, ref.ID)); r.Result != nil {
+		t.Fatal(r)
+	}
+	<-tl.stopped
+	if len(b.streams) != 0 || len(b.calls) != 0 {
+		t.Fatal(b.streams, b.calls)
+	}
+
+	ref = call(`{"id":1,"scope":"test","method":"None","params":[]}`).Result.(streamRef)
+	if r := call(fmt.Sprintf(`{"id":2,"scope":"test","stream":%d,"op":"next"}`, ref.ID)); r.Result != (streamDone{Done: true}) {
+		t.Fatal(r)
+	}
+
+	// Removing the binding closes its streams and cancels its calls
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	tl.stopped = make(chan struct{})
+	call(`{"id":3,"scope":"test","method":"Tail","params":["a","b"]}`)
+	b.close()
+	<-tl.stopped
+	if len(b.streams) != 0 || b.ctx.Err() != context.Canceled {
+		t.Fatal(b.streams, b.ctx.Err())
+	}
+}
+
+func TestLookupWebView(t *testing.T) {
+	var a, b int
+	w := &webview{w: unsafe.Pointer(&a)}
+	m.Lock()
+	cbs[w] = func(w WebView, data string) {}
+	m.Unlock()
+	defer func() {
+		m.Lock()
+		delete(cbs, w)
+		m.Unlock()
+	}()
+	if wv := lookupWebView(unsafe.Pointer(&a)); wv != w {
+		t.Fatal(wv)
+	}
+	if wv := lookupWebView(unsafe.Pointer(&b)); wv != nil {
+		t.Fatal(wv)
+	}
+}
+
+func TestCallJS(t *testing.T) {
+	w := &webview{}
+	for _, fn := range []string{"", "a b", "alert()", "a..b"} {
+		if _, err := w.CallJS(context.Background(), fn); err == nil {
+			t.Fatal(fn)
+		}
+	}
+	r := evalResult{}
+	if err := json.Unmarshal([]byte(`{"error":{"name":"TypeError","message":"x is undefined","stack":"f@1"}}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Result != nil || r.Error == nil || r.Error.Error() != "TypeError: x is undefined" || r.Error.Stack != "f@1" {
+		t.Fatal(r)
+	}
+}
+
+// TestEvalAsync checks that the callbacks of EvalAsync() only run when the
+// webview reports the results, so that they are never called recursively.
+func TestEvalAsync(t *testing.T) {
+	w := &webview{}
+	var got []string
+	var nested uintptr
+	first, _ := w.startEval(func(result json.RawMessage, err error) {
+		got = append(got, "first "+string(result))
+		// Evaluating from the callback does not report the result now
+		nested, _ = w.startEval(func(result json.RawMessage, err error) {
+			got = append(got, "nested "+string(result))
+		})
+		got = append(got, "first done")
+	})
+	second, _ := w.startEval(func(result json.RawMessage, err error) {
+		got = append(got, fmt.Sprint("second ", err))
+	})
+	if len(got) != 0 {
+		t.Fatal(got)
+	}
+	finishEval(first, `{"result":1}`, nil)
+	finishEval(second, "", errors.New("the page can not be loaded"))
+	finishEval(nested, `{"result":2}`, nil)
+	finishEval(nested, `{"result":3}`, nil)
+	if s := strings.Join(got, ", "); s != "first 1, first done, second the page can not be loaded, nested 2" {
+		t.Fatal(s)
+	}
+}
+
+func TestEvalPromise(t *testing.T) {
+	w := &webview{}
+	results := map[uintptr]evalResult{}
+	done := func(id uintptr) func(evalResult) {
+		return func(res evalResult) { results[id] = res }
+	}
+
+	// The promise settles after its evaluation reported it as pending
+	w.await(1, 0, "n1", done(1))
+	if !w.runtimeCall(`{"webview":"eval","id":1,"nonce":"n1","result":{"a":1}}`) {
+		t.Fatal()
+	}
+	if r := results[1]; string(r.Result) != `{"a":1}` || r.Error != nil {
+		t.Fatal(r)
+	}
+
+	// The promise settles before
+	w.runtimeCall(`{"webview":"eval","id":2,"nonce":"n2","error":{"name":"Error","message":"no"}}`)
+	if _, ok := results[2]; ok {
+		t.Fatal(results)
+	}
+	w.await(2, 0, "n2", done(2))
+	if r := results[2]; r.Error == nil || r.Error.Error() != "Error: no" {
+		t.Fatal(r)
+	}
+
+	// Other scripts can't settle it without the nonce
+	w.await(3, 0, "n3", done(3))
+	w.runtimeCall(`{"webview":"eval","id":3,"result":"forged"}`)
+	w.runtimeCall(`{"webview":"eval","id":4,"nonce":"forged","result":"forged"}`)
+	w.await(4, 0, "n4", done(4))
+	if len(results) != 2 {
+		t.Fatal(results)
+	}
+
+	// Loading another page fails the pending evaluations, and the
+	// evaluations of the previous page that are reported as pending later
+	w.load(LoadEvent{Type: LoadStarted})
+	if r := results[3]; r.err == nil {
+		t.Fatal(r)
+	}
+	if r := results[4]; r.err == nil {
+		t.Fatal(r)
+	}
+	w.await(5, 0, "n5", done(5))
+	if r := results[5]; r.err == nil {
+		t.Fatal(r)
+	}
+	if len(w.awaiting) != 0 || len(w.settled) != 0 {
+		t.Fatal(w.awaiting, w.settled)
+	}
+}
+
+func TestEvalUndefined(t *testing.T) {
+	w := &webview{}
+	var result json.RawMessage
+	id, nonce := w.startEval(func(r json.RawMessage, err error) { result = r })
+	if len(nonce) != 32 {
+		t.Fatal(nonce)
+	}
+	// JSON.stringify() drops the result if it is a function
+	finishEval(id, `{}`, nil)
+	if string(result) != "null" {
+		t.Fatal(string(result))
+	}
+}
+
+func TestStyleSheetJS(t *testing.T) {
+	s := &styleSheet{id: 2, css: `body { font-family: "Sans"; }`}
+	if js := s.JS(); js != `window.webview._style(2,"body { font-family: \"Sans\"; }");` {
+		t.Fatal(js)
+	}
+	s.removed = true
+	if js := s.JS(); js != `window.webview._style(2,null);` {
+		t.Fatal(js)
+	}
+	if err := s.Remove(); err == nil {
+		t.Fatal()
+	}
+	if err := s.ReplaceCSS("body {}"); err == nil {
+		t.Fatal()
+	}
+}
+
+func TestNavigationPolicy(t *testing.T) {
+	w := &webview{}
+	if d := w.navigate(NavigationRequest{URL: "https://example.com"}); d != NavigationAllow {
+		t.Fatal(d)
+	}
+	var panicErr *PanicError
+	w.panicHandler = func(err *PanicError) { panicErr = err }
+	w.navigationPolicy = func(req NavigationRequest) NavigationDecision {
+		switch {
+		case req.NewWindow:
+			return NavigationOpenExternal
+		case strings.HasPrefix(req.URL, "http://localhost/"):
+			return NavigationAllow
+		case req.URL == "panic":
+			panic("oops")
+		}
+		return NavigationBlock
+	}
+	for req, decision := range map[NavigationRequest]NavigationDecision{
+		{URL: "http://localhost/index.html"}:                                NavigationAllow,
+		{URL: "https://example.com", Type: NavigationLink}:                  NavigationBlock,
+		{URL: "https://example.com", Type: NavigationLink, NewWindow: true}: NavigationOpenExternal,
+		{URL: "panic"}: NavigationBlock,
+	} {
+		if d := w.navigate(req); d != decision {
+			t.Fatal(req, d)
+		}
+	}
+	if panicErr == nil || panicErr.Where != "NavigationPolicy" {
+		t.Fatal(panicErr)
+	}
+}
+
+func TestClose(t *testing.T) {
+	w := &webview{}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	url, _, err := Serve(http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.closers = append(w.closers, takeServer(url))
+	// The loop ends when the window is closed
+	w.close()
+	if w.ctx.Err() != context.Canceled {
+		t.Fatal(w.ctx.Err())
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Fatal("server is not closed")
+	}
+}
+
+func TestEmit(t *testing.T) {
+	w := &webview{}
+	for i := 1; i <= 3; i++ {
+		if err := w.Emit("tick", map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Emit("tick", func() {}); err == nil {
+		t.Fatal("functions can not be emitted")
+	}
+	// The events are queued until the runtime is ready, in order
+	events := w.runtimeReady()
+	if s := strings.Join(events, ""); s != `window.webview._emit("tick",{"n":1});`+
+		`window.webview._emit("tick",{"n":2});`+
+		`window.webview._emit("tick",{"n":3});` {
+		t.Fatal(s)
+	}
+	if len(w.events) != 0 || !w.ready {
+		t.Fatal(w.events, w.ready)
+	}
+	// A new page queues them again
+	w.load(LoadEvent{Type: LoadStarted})
+	w.Emit("tick", nil)
+	if len(w.events) != 1 || w.ready {
+		t.Fatal(w.events, w.ready)
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	w := &webview{loaded: make(chan struct{})}
+	waiting := func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		return w.WaitReady(ctx) == context.DeadlineExceeded
+	}
+	if !waiting() {
+		t.Fatal("the first page is not loaded yet")
+	}
+	w.loadFinished()
+	if waiting() {
+		t.Fatal("the first page is loaded")
+	}
+	// Navigating to another page waits for it again
+	w.load(LoadEvent{Type: LoadStarted, URL: "https://example.com"})
+	done := make(chan error)
+	go func() { done <- w.WaitReady(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatal("the second page is not loaded yet", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	w.loadFinished()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOnLoad(t *testing.T) {
+	w := &webview{ready: true}
+	var panicErr *PanicError
+	w.panicHandler = func(err *PanicError) { panicErr = err }
+	var events []LoadEventType
+	w.OnLoad(func(e LoadEvent) {
+		if e.Type == LoadFailed {
+			panic(e.Err)
+		}
 	})
+	w.OnLoad(func(e LoadEvent) { events = append(events, e.Type) })
+	w.load(LoadEvent{Type: LoadStarted, URL: "http://localhost/"})
+	if w.ready {
+		t.Fatal("events must be queued until the new page is ready")
+	}
+	w.load(LoadEvent{Type: LoadFailed, URL: "http://localhost/", Err: errors.New("connection refused")})
+	// The error page is not reported as a loaded page
+	w.load(LoadEvent{Type: LoadFinished, URL: "http://localhost/"})
+	if !reflect.DeepEqual(events, []LoadEventType{LoadStarted, LoadFailed}) {
+		t.Fatal(events)
+	}
+	if panicErr == nil || panicErr.Where != "OnLoad" {
+		t.Fatal(panicErr)
+	}
+	w.loaded = make(chan struct{})
+	w.OnReady(func() {})
+	if fns, ok := w.loadFinished(); ok || fns != nil {
+		t.Fatal(fns, ok)
+	}
+	w.load(LoadEvent{Type: LoadStarted, URL: "http://localhost/"})
+	w.load(LoadEvent{Type: LoadFinished, URL: "http://localhost/"})
+	if fns, ok := w.loadFinished(); !ok || len(fns) != 1 {
+		t.Fatal(fns, ok)
+	}
+	if events[len(events)-1] != LoadFinished {
+		t.Fatal(events)
+	}
+}
+
+func TestSchemeHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Add("Cache-Control", "no-cache")
+	h.Add("Cache-Control", "no-store")
+	h.Set("X-Injected", "a\r\nSet-Cookie: b")
+	s := formatSchemeHeaders(h)
+	if s != "Cache-Control: no-cache, no-store\nContent-Type: text/html; charset=utf-8\nX-Injected: a  Set-Cookie: b\n" {
+		t.Fatal(s)
+	}
+	h = parseSchemeHeaders("accept: text/html\nX-Requested-With: app\ninvalid\n")
+	if !reflect.DeepEqual(h, http.Header{"Accept": {"text/html"}, "X-Requested-With": {"app"}}) {
+		t.Fatal(h)
+	}
+}
+
+func TestCheckAssets(t *testing.T) {
+	assets := fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}
+	h := http.NotFoundHandler()
+	for _, test := range []struct {
+		settings Settings
+		ok       bool
+	}{
+		{Settings{Schemes: map[string]http.Handler{"app": h}}, true},
+		{Settings{Assets: assets}, true},
+		{Settings{Assets: assets, Schemes: map[string]http.Handler{"res": h}}, true},
+		{Settings{Assets: assets, Schemes: map[string]http.Handler{"app": h}}, false},
+		{Settings{Assets: assets, Schemes: map[string]http.Handler{"APP": h}}, false},
+	} {
+		if err := checkAssets(test.settings); (err == nil) != test.ok {
+			t.Fatal(test.settings.Schemes, err)
+		}
+	}
+}
+
+func TestAssetHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte("<h1>index</h1>")},
+		"app.js":          {Data: []byte("console.log('app')")},
+		"docs/index.html": {Data: []byte("<h1>docs</h1>")},
+		"blob":            {Data: []byte{0, 1, 2}},
+	}
+	h := AssetHandler(fsys, AssetOptions{Fallback: "index.html", CacheControl: "no-cache"})
+	for _, test := range []struct {
+		Method string
+		Path   string
+		Status int
+		Type   string
+		Body   string
+	}{
+		{"GET", "/", 200, "text/html; charset=utf-8", "<h1>index</h1>"},
+		{"GET", "/app.js", 200, "text/javascript; charset=utf-8", "console.log('app')"},
+		{"GET", "/docs/", 200, "text/html; charset=utf-8", "<h1>docs</h1>"},
+		{"GET", "/../app.js", 200, "text/javascript; charset=utf-8", "console.log('app')"},
+		{"GET", "/blob", 200, "application/octet-stream", "\x00\x01\x02"},
+		{"GET", "/todos/42", 200, "text/html; charset=utf-8", "<h1>index</h1>"},
+		{"GET", "/missing.js", 404, "text/plain; charset=utf-8", "404 page not found\n"},
+		{"HEAD", "/app.js", 200, "text/javascript; charset=utf-8", ""},
+		{"POST", "/app.js", 405, "text/plain; charset=utf-8", "Method Not Allowed\n"},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(test.Method, "app://localhost"+test.Path, nil))
+		if rec.Code != test.Status || rec.Header().Get("Content-Type") != test.Type || rec.Body.String() != test.Body {
+			t.Fatal(test, rec.Code, rec.Header(), rec.Body.String())
+		}
+		if rec.Code == 200 && rec.Header().Get("Cache-Control") != "no-cache" {
+			t.Fatal(test, rec.Header())
+		}
+	}
+
+	h = AssetHandler(fsys, AssetOptions{ContentType: func(name string) string {
+		if name == "blob" {
+			return "application/x-custom"
+		}
+		return ""
+	}})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "app://localhost/blob", nil))
+	if rec.Header().Get("Content-Type") != "application/x-custom" {
+		t.Fatal(rec.Header())
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "app://localhost/todos/42", nil))
+	if rec.Code != 404 {
+		t.Fatal(rec.Code)
+	}
+}
+
+func TestServe(t *testing.T) {
+	url, closer, err := Serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s?%s", r.URL.Path, r.URL.RawQuery)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	if !strings.HasPrefix(url, "http://127.0.0.1:") || !strings.Contains(url, "/?webview_token=") {
+		t.Fatal(url)
+	}
+	base := strings.SplitN(url, "?", 2)[0]
+
+	// Requests without the token are rejected
+	if res, err := http.Get(base + "app.js"); err != nil || res.StatusCode != http.StatusForbidden {
+		t.Fatal(res, err)
+	}
+	if res, err := http.Get(base + "?webview_token=invalid"); err != nil || res.StatusCode != http.StatusForbidden {
+		t.Fatal(res, err)
+	}
+
+	// The token is exchanged for a cookie
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	res, err := client.Get(strings.Replace(url, "/?", "/todos?id=1&", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(b) != "/todos?id=1" {
+		t.Fatal(res.StatusCode, string(b))
+	}
+	res, err = client.Get(base + "app.js")
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatal(res, err)
+	}
+	res.Body.Close()
+
+	// The server is closed with the window that loads its URL
+	if s := takeServer(url); s == nil || s != closer {
+		t.Fatal(s)
+	}
+	if s := takeServer(url); s != nil {
+		t.Fatal(s)
+	}
+	closer.Close()
+	if _, err := client.Get(base); err == nil {
+		t.Fatal("server is not closed")
+	}
+
+	// Every server has a cookie of its own
+	hello := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	url1, closer1, err := Serve(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer1.Close()
+	url2, closer2, err := Serve(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer2.Close()
+	jar, _ = cookiejar.New(nil)
+	client = &http.Client{Jar: jar}
+	for _, url := range []string{url1, url2, strings.SplitN(url1, "?", 2)[0], strings.SplitN(url2, "?", 2)[0]} {
+		if res, err := client.Get(url); err != nil || res.StatusCode != http.StatusOK {
+			t.Fatal(url, res, err)
+		}
+	}
+
+	s := &server{addr: "127.0.0.1:8080", token: "secret"}
+	if c := s.cookie(); c != "webview_token_8080" {
+		t.Fatal(c)
+	}
+	for path, url := range map[string]string{
+		"/":            "http://127.0.0.1:8080/?webview_token=secret",
+		"/#/settings":  "http://127.0.0.1:8080/?webview_token=secret#/settings",
+		"/search?q=go": "http://127.0.0.1:8080/search?q=go&webview_token=secret",
+	} {
+		if u := s.url(path); u != url {
+			t.Fatal(path, u)
+		}
+	}
 }